@@ -0,0 +1,120 @@
+package certificate
+
+import (
+	"crypto/tls"
+	"sync"
+
+	"github.com/go-acme/lego/v4/challenge/tlsalpn01"
+)
+
+// ChallengeType identifies which ACME challenge mechanism should be used to
+// prove ownership of a domain.
+type ChallengeType string
+
+const (
+	// ChallengeHTTP01 proves ownership by serving a token over plain HTTP at
+	// /.well-known/acme-challenge/.
+	ChallengeHTTP01 ChallengeType = "http"
+	// ChallengeTLSALPN01 proves ownership via a self-signed certificate
+	// presented during the TLS handshake, using the acme-tls/1 protocol.
+	ChallengeTLSALPN01 ChallengeType = "tls-alpn"
+	// ChallengeDNS01 proves ownership via a DNS TXT record, and is the only
+	// challenge type that can validate wildcard domains.
+	ChallengeDNS01 ChallengeType = "dns"
+)
+
+// IsWildcardCompatible reports whether c can be used to validate a wildcard
+// domain. Only DNS-01 can.
+func (c ChallengeType) IsWildcardCompatible() bool {
+	return c == ChallengeDNS01 || c == ""
+}
+
+// ChallengeStore holds in-flight ACME challenge responses so that the
+// frontend can answer HTTP-01 and TLS-ALPN-01 validation requests itself,
+// ahead of the normal proxy path.
+type ChallengeStore struct {
+	mutex  sync.RWMutex
+	tokens map[string]string
+	certs  map[string]*tls.Certificate
+}
+
+// NewChallengeStore creates an empty ChallengeStore.
+func NewChallengeStore() *ChallengeStore {
+	return &ChallengeStore{
+		tokens: map[string]string{},
+		certs:  map[string]*tls.Certificate{},
+	}
+}
+
+// KeyAuthorization returns the key authorization for an in-flight HTTP-01
+// challenge token, for use by the frontend's /.well-known/acme-challenge/
+// handler.
+func (s *ChallengeStore) KeyAuthorization(token string) (string, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	keyAuth, ok := s.tokens[token]
+	return keyAuth, ok
+}
+
+// CertificateForSNI returns the challenge certificate for an in-flight
+// TLS-ALPN-01 challenge, for use by the frontend's TLS handshake when the
+// client negotiates the acme-tls/1 protocol.
+func (s *ChallengeStore) CertificateForSNI(domain string) (*tls.Certificate, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	cert, ok := s.certs[domain]
+	return cert, ok
+}
+
+// HTTPProvider returns a lego challenge.Provider that serves HTTP-01 tokens
+// out of this store.
+func (s *ChallengeStore) HTTPProvider() *httpChallengeProvider {
+	return &httpChallengeProvider{store: s}
+}
+
+// TLSALPNProvider returns a lego challenge.Provider that serves TLS-ALPN-01
+// challenge certificates out of this store.
+func (s *ChallengeStore) TLSALPNProvider() *tlsAlpnChallengeProvider {
+	return &tlsAlpnChallengeProvider{store: s}
+}
+
+type httpChallengeProvider struct {
+	store *ChallengeStore
+}
+
+func (p *httpChallengeProvider) Present(_, token, keyAuth string) error {
+	p.store.mutex.Lock()
+	defer p.store.mutex.Unlock()
+	p.store.tokens[token] = keyAuth
+	return nil
+}
+
+func (p *httpChallengeProvider) CleanUp(_, token, _ string) error {
+	p.store.mutex.Lock()
+	defer p.store.mutex.Unlock()
+	delete(p.store.tokens, token)
+	return nil
+}
+
+type tlsAlpnChallengeProvider struct {
+	store *ChallengeStore
+}
+
+func (p *tlsAlpnChallengeProvider) Present(domain, _, keyAuth string) error {
+	cert, err := tlsalpn01.ChallengeCert(domain, keyAuth)
+	if err != nil {
+		return err
+	}
+
+	p.store.mutex.Lock()
+	defer p.store.mutex.Unlock()
+	p.store.certs[domain] = cert
+	return nil
+}
+
+func (p *tlsAlpnChallengeProvider) CleanUp(domain, _, _ string) error {
+	p.store.mutex.Lock()
+	defer p.store.mutex.Unlock()
+	delete(p.store.certs, domain)
+	return nil
+}
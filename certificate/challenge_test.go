@@ -0,0 +1,63 @@
+package certificate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ChallengeStore_KeyAuthorization_returnsFalseForUnknownToken(t *testing.T) {
+	store := NewChallengeStore()
+
+	_, ok := store.KeyAuthorization("unknown")
+
+	assert.False(t, ok)
+}
+
+func Test_ChallengeStore_HTTPProvider_PresentThenCleanUp(t *testing.T) {
+	store := NewChallengeStore()
+	provider := store.HTTPProvider()
+
+	require.NoError(t, provider.Present("example.com", "token", "key-auth"))
+
+	keyAuth, ok := store.KeyAuthorization("token")
+	require.True(t, ok)
+	assert.Equal(t, "key-auth", keyAuth)
+
+	require.NoError(t, provider.CleanUp("example.com", "token", "key-auth"))
+
+	_, ok = store.KeyAuthorization("token")
+	assert.False(t, ok)
+}
+
+func Test_ChallengeStore_CertificateForSNI_returnsFalseForUnknownDomain(t *testing.T) {
+	store := NewChallengeStore()
+
+	_, ok := store.CertificateForSNI("example.com")
+
+	assert.False(t, ok)
+}
+
+func Test_ChallengeStore_TLSALPNProvider_PresentThenCleanUp(t *testing.T) {
+	store := NewChallengeStore()
+	provider := store.TLSALPNProvider()
+
+	require.NoError(t, provider.Present("example.com", "token", "key-auth"))
+
+	cert, ok := store.CertificateForSNI("example.com")
+	require.True(t, ok)
+	assert.NotNil(t, cert)
+
+	require.NoError(t, provider.CleanUp("example.com", "token", "key-auth"))
+
+	_, ok = store.CertificateForSNI("example.com")
+	assert.False(t, ok)
+}
+
+func Test_ChallengeType_IsWildcardCompatible(t *testing.T) {
+	assert.True(t, ChallengeDNS01.IsWildcardCompatible())
+	assert.True(t, ChallengeType("").IsWildcardCompatible())
+	assert.False(t, ChallengeHTTP01.IsWildcardCompatible())
+	assert.False(t, ChallengeTLSALPN01.IsWildcardCompatible())
+}
@@ -0,0 +1,14 @@
+package certificate
+
+import "crypto/x509"
+
+// x509MarshalKey encodes a private key to PKCS#8 DER for storage.
+func x509MarshalKey(key any) ([]byte, error) {
+	return x509.MarshalPKCS8PrivateKey(key)
+}
+
+// x509ParseKey decodes a PKCS#8 DER private key previously written by
+// x509MarshalKey.
+func x509ParseKey(der []byte) (any, error) {
+	return x509.ParsePKCS8PrivateKey(der)
+}
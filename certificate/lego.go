@@ -0,0 +1,260 @@
+package certificate
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	legocert "github.com/go-acme/lego/v4/certificate"
+	acmechallenge "github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/lego"
+	"github.com/go-acme/lego/v4/registration"
+)
+
+// LegoSupplierConfig configures a LegoSupplier.
+type LegoSupplierConfig struct {
+	// Path is where the ACME account's private key is persisted. The
+	// registration itself is not stored.
+	Path string
+	// Email is the contact address used for the ACME account.
+	Email string
+	// DirUrl is the ACME directory endpoint to use.
+	DirUrl string
+	// KeyType is the key algorithm to request certificates with.
+	KeyType certcrypto.KeyType
+	// DnsProvider, if set, enables the dns-01 challenge.
+	DnsProvider acmechallenge.Provider
+	// Challenges, if set, enables the http-01 and tls-alpn-01 challenges,
+	// backed by the frontend rather than a standalone listener.
+	Challenges *ChallengeStore
+}
+
+// LegoSupplier obtains certificates from an ACME provider via lego, using
+// whichever challenge type each domain has been registered with.
+type LegoSupplier struct {
+	client *lego.Client
+	config *LegoSupplierConfig
+
+	mutex      sync.RWMutex
+	challenges map[string]ChallengeType
+	mustStaple map[string]bool
+
+	// issueMutex serializes selectChallenge/Obtain pairs. The lego client
+	// only holds one challenge provider at a time, so two concurrent
+	// issuances (e.g. a scheduled renewal racing an admin-triggered one)
+	// could otherwise have the provider swapped out from under an
+	// in-flight ACME validation.
+	issueMutex sync.Mutex
+}
+
+// NewLegoSupplier creates a LegoSupplier, loading the ACME account key from
+// cfg.Path or generating a new one if the file doesn't exist. Since only the
+// key is persisted, the account is registered with the ACME directory (and
+// the key saved back to cfg.Path) on every call.
+func NewLegoSupplier(cfg *LegoSupplierConfig) (*LegoSupplier, error) {
+	user, err := loadOrCreateUser(cfg.Path, cfg.Email)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load ACME user: %w", err)
+	}
+
+	legoConfig := lego.NewConfig(user)
+	legoConfig.CADirURL = cfg.DirUrl
+	legoConfig.Certificate.KeyType = cfg.KeyType
+
+	client, err := lego.NewClient(legoConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create ACME client: %w", err)
+	}
+
+	if user.Registration == nil {
+		reg, err := client.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
+		if err != nil {
+			return nil, fmt.Errorf("unable to register ACME account: %w", err)
+		}
+		user.Registration = reg
+		if err := saveUser(cfg.Path, user); err != nil {
+			return nil, fmt.Errorf("unable to save ACME account: %w", err)
+		}
+	}
+
+	return &LegoSupplier{
+		client:     client,
+		config:     cfg,
+		challenges: map[string]ChallengeType{},
+		mustStaple: map[string]bool{},
+	}, nil
+}
+
+// RegisterChallenge records the challenge type that should be used to
+// validate domain. It rejects http-01/tls-alpn-01 for wildcard domains,
+// which can only be validated via dns-01.
+func (l *LegoSupplier) RegisterChallenge(domain string, c ChallengeType) error {
+	if strings.HasPrefix(domain, "*.") && !c.IsWildcardCompatible() {
+		return fmt.Errorf("wildcard domain %s cannot use the %s challenge", domain, c)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.challenges[domain] = c
+	return nil
+}
+
+// RegisterMustStaple records whether certificates issued for domain should
+// request the OCSP Must-Staple TLS feature extension, so that a revoked
+// certificate can't be used without a current OCSP staple.
+func (l *LegoSupplier) RegisterMustStaple(domain string, mustStaple bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.mustStaple[domain] = mustStaple
+}
+
+// Registrations is an opaque snapshot of the challenge and must-staple state
+// recorded by RegisterChallenge/RegisterMustStaple, as returned by Snapshot.
+type Registrations struct {
+	challenges map[string]ChallengeType
+	mustStaple map[string]bool
+}
+
+// Snapshot captures the current challenge and must-staple registrations, so
+// they can be restored with Restore if a subsequent batch of registrations
+// turns out to belong to a route set that was ultimately rejected.
+func (l *LegoSupplier) Snapshot() *Registrations {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	snapshot := &Registrations{
+		challenges: make(map[string]ChallengeType, len(l.challenges)),
+		mustStaple: make(map[string]bool, len(l.mustStaple)),
+	}
+	for domain, c := range l.challenges {
+		snapshot.challenges[domain] = c
+	}
+	for domain, ms := range l.mustStaple {
+		snapshot.mustStaple[domain] = ms
+	}
+	return snapshot
+}
+
+// Restore replaces the current challenge and must-staple registrations with
+// a previously captured Snapshot.
+func (l *LegoSupplier) Restore(snapshot *Registrations) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.challenges = snapshot.challenges
+	l.mustStaple = snapshot.mustStaple
+}
+
+// Obtain implements Supplier, requesting a certificate for subject using the
+// challenge type registered for it (defaulting to dns-01).
+func (l *LegoSupplier) Obtain(subject string, altNames []string) (*tls.Certificate, error) {
+	// selectChallenge and client.Certificate.Obtain must run as one unit:
+	// they share the single lego.Client's challenge provider, which is set
+	// just before the ACME round trip that relies on it.
+	l.issueMutex.Lock()
+	defer l.issueMutex.Unlock()
+
+	if err := l.selectChallenge(subject); err != nil {
+		return nil, err
+	}
+
+	l.mutex.RLock()
+	mustStaple := l.mustStaple[subject]
+	l.mutex.RUnlock()
+
+	request := legocert.ObtainRequest{
+		Domains:    append([]string{subject}, altNames...),
+		Bundle:     true,
+		MustStaple: mustStaple,
+	}
+
+	resource, err := l.client.Certificate.Obtain(request)
+	if err != nil {
+		return nil, fmt.Errorf("unable to obtain certificate: %w", err)
+	}
+
+	cert, err := tls.X509KeyPair(resource.Certificate, resource.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse issued certificate: %w", err)
+	}
+
+	return &cert, nil
+}
+
+func (l *LegoSupplier) selectChallenge(subject string) error {
+	l.mutex.RLock()
+	c := l.challenges[subject]
+	l.mutex.RUnlock()
+
+	l.client.Challenge.Remove(acmechallenge.HTTP01)
+	l.client.Challenge.Remove(acmechallenge.TLSALPN01)
+	l.client.Challenge.Remove(acmechallenge.DNS01)
+
+	switch c {
+	case ChallengeHTTP01:
+		if l.config.Challenges == nil {
+			return fmt.Errorf("http challenge requested for %s but no challenge store is configured", subject)
+		}
+		return l.client.Challenge.SetHTTP01Provider(l.config.Challenges.HTTPProvider())
+	case ChallengeTLSALPN01:
+		if l.config.Challenges == nil {
+			return fmt.Errorf("tls-alpn challenge requested for %s but no challenge store is configured", subject)
+		}
+		return l.client.Challenge.SetTLSALPN01Provider(l.config.Challenges.TLSALPNProvider())
+	case ChallengeDNS01, "":
+		if l.config.DnsProvider == nil {
+			return fmt.Errorf("dns challenge requested for %s but no dns provider is configured", subject)
+		}
+		return l.client.Challenge.SetDNS01Provider(l.config.DnsProvider)
+	default:
+		return fmt.Errorf("unknown challenge type %q for %s", c, subject)
+	}
+}
+
+func loadOrCreateUser(path, email string) (*acmeUser, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate account key: %w", err)
+		}
+		return &acmeUser{Email: email, key: key}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to read user data: %w", err)
+	}
+
+	return parseUser(data, email)
+}
+
+// acmeUser implements lego's registration.User interface.
+type acmeUser struct {
+	Email        string
+	Registration *registration.Resource
+	key          *ecdsa.PrivateKey
+}
+
+func (u *acmeUser) GetEmail() string                        { return u.Email }
+func (u *acmeUser) GetRegistration() *registration.Resource { return u.Registration }
+func (u *acmeUser) GetPrivateKey() crypto.PrivateKey        { return u.key }
+
+func parseUser(data []byte, email string) (*acmeUser, error) {
+	key, err := x509.ParseECPrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse account key: %w", err)
+	}
+	return &acmeUser{Email: email, key: key}, nil
+}
+
+func saveUser(path string, user *acmeUser) error {
+	der, err := x509.MarshalECPrivateKey(user.key)
+	if err != nil {
+		return fmt.Errorf("unable to marshal account key: %w", err)
+	}
+	return os.WriteFile(path, der, 0600)
+}
@@ -0,0 +1,80 @@
+package certificate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLegoSupplier builds a LegoSupplier with just enough state for
+// RegisterChallenge/Snapshot/Restore, which don't touch the ACME client.
+func newTestLegoSupplier() *LegoSupplier {
+	return &LegoSupplier{
+		challenges: map[string]ChallengeType{},
+		mustStaple: map[string]bool{},
+	}
+}
+
+func Test_LegoSupplier_RegisterChallenge_rejectsIncompatibleChallengeForWildcardDomain(t *testing.T) {
+	supplier := newTestLegoSupplier()
+
+	err := supplier.RegisterChallenge("*.example.com", ChallengeHTTP01)
+
+	assert.Error(t, err)
+}
+
+func Test_LegoSupplier_RegisterChallenge_allowsDnsChallengeForWildcardDomain(t *testing.T) {
+	supplier := newTestLegoSupplier()
+
+	err := supplier.RegisterChallenge("*.example.com", ChallengeDNS01)
+
+	assert.NoError(t, err)
+	assert.Equal(t, ChallengeDNS01, supplier.challenges["*.example.com"])
+}
+
+func Test_LegoSupplier_RegisterChallenge_allowsAnyChallengeForNonWildcardDomain(t *testing.T) {
+	supplier := newTestLegoSupplier()
+
+	err := supplier.RegisterChallenge("example.com", ChallengeHTTP01)
+
+	assert.NoError(t, err)
+	assert.Equal(t, ChallengeHTTP01, supplier.challenges["example.com"])
+}
+
+func Test_LegoSupplier_RegisterMustStaple_recordsSetting(t *testing.T) {
+	supplier := newTestLegoSupplier()
+
+	supplier.RegisterMustStaple("example.com", true)
+
+	assert.True(t, supplier.mustStaple["example.com"])
+}
+
+func Test_LegoSupplier_SnapshotRestore_roundTrips(t *testing.T) {
+	supplier := newTestLegoSupplier()
+	require.NoError(t, supplier.RegisterChallenge("example.com", ChallengeHTTP01))
+	supplier.RegisterMustStaple("example.com", true)
+
+	snapshot := supplier.Snapshot()
+
+	require.NoError(t, supplier.RegisterChallenge("example.com", ChallengeDNS01))
+	supplier.RegisterMustStaple("example.com", false)
+	require.NoError(t, supplier.RegisterChallenge("other.com", ChallengeTLSALPN01))
+
+	supplier.Restore(snapshot)
+
+	assert.Equal(t, ChallengeHTTP01, supplier.challenges["example.com"])
+	assert.True(t, supplier.mustStaple["example.com"])
+	_, ok := supplier.challenges["other.com"]
+	assert.False(t, ok)
+}
+
+func Test_LegoSupplier_Snapshot_isIndependentOfLaterChanges(t *testing.T) {
+	supplier := newTestLegoSupplier()
+	require.NoError(t, supplier.RegisterChallenge("example.com", ChallengeHTTP01))
+
+	snapshot := supplier.Snapshot()
+	require.NoError(t, supplier.RegisterChallenge("example.com", ChallengeDNS01))
+
+	assert.Equal(t, ChallengeHTTP01, snapshot.challenges["example.com"])
+}
@@ -0,0 +1,158 @@
+package certificate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/csmith/centauri/metrics"
+	"golang.org/x/crypto/ocsp"
+)
+
+// Manager is a proxy.CertificateProvider backed by a Store and a single
+// Supplier, renewing certificates as they approach expiry.
+type Manager struct {
+	store           *Store
+	supplier        Supplier
+	minCertValidity time.Duration
+	minOcspValidity time.Duration
+
+	ocspMutex sync.Mutex
+	ocspState map[string]*OCSPStatus
+}
+
+// NewManager creates a Manager that issues certificates from supplier,
+// caching them in store and renewing them once less than minCertValidity
+// remains on the certificate. minOcspValidity only governs RefreshOCSP,
+// which refreshes a staple once less than that remains before its
+// NextUpdate; it never causes a certificate to be renewed.
+func NewManager(store *Store, supplier Supplier, minCertValidity, minOcspValidity time.Duration) *Manager {
+	return &Manager{
+		store:           store,
+		supplier:        supplier,
+		minCertValidity: minCertValidity,
+		minOcspValidity: minOcspValidity,
+		ocspState:       map[string]*OCSPStatus{},
+	}
+}
+
+// GetCertificate implements proxy.CertificateProvider. The preferredSupplier
+// argument is accepted for interface compatibility with resolvers that
+// multiplex several suppliers; this Manager always uses the one it was
+// constructed with.
+func (m *Manager) GetCertificate(preferredSupplier string, subject string, altNames []string) (*tls.Certificate, error) {
+	if cert := m.store.Get(subject); cert != nil && m.isFresh(cert) {
+		return cert, nil
+	}
+
+	cert, err := m.supplier.Obtain(subject, altNames)
+	if err != nil {
+		return nil, fmt.Errorf("unable to obtain certificate for %s: %w", subject, err)
+	}
+
+	if err := m.store.Put(subject, cert); err != nil {
+		return nil, fmt.Errorf("unable to store certificate for %s: %w", subject, err)
+	}
+
+	return cert, nil
+}
+
+// OCSPStatus describes the outcome of the most recent OCSP staple refresh
+// attempt for a subject.
+type OCSPStatus struct {
+	Subject    string
+	NextUpdate time.Time
+	CheckedAt  time.Time
+	LastError  error
+}
+
+// RefreshOCSP fetches a fresh OCSP staple for every certificate in this
+// Manager's store (including any put there by another Manager sharing the
+// same store) whose existing staple is missing or within minOcspValidity of
+// its NextUpdate. Failures are recorded per subject rather than returned, so
+// a single unreachable responder doesn't stop the others from refreshing.
+func (m *Manager) RefreshOCSP() {
+	for _, subject := range m.store.Subjects() {
+		cert := m.store.Get(subject)
+		if cert == nil {
+			continue
+		}
+		if status, ok := m.ocspStatus(subject); ok && time.Until(status.NextUpdate) > m.minOcspValidity {
+			continue
+		}
+		m.refreshOCSPFor(subject, cert)
+	}
+}
+
+func (m *Manager) refreshOCSPFor(subject string, cert *tls.Certificate) {
+	leaf, issuer, err := certificateChain(cert)
+	if err == nil {
+		var parsed *ocsp.Response
+		parsed, err = m.fetchAndStaple(subject, leaf, issuer)
+		if err == nil {
+			m.recordOCSP(subject, parsed.NextUpdate, nil)
+			return
+		}
+	}
+	m.recordOCSP(subject, time.Time{}, err)
+}
+
+func (m *Manager) fetchAndStaple(subject string, leaf, issuer *x509.Certificate) (*ocsp.Response, error) {
+	raw, parsed, err := fetchOCSPStaple(leaf, issuer)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.store.SetOCSPStaple(subject, raw); err != nil {
+		return nil, fmt.Errorf("unable to save OCSP staple for %s: %w", subject, err)
+	}
+	return parsed, nil
+}
+
+func (m *Manager) recordOCSP(subject string, nextUpdate time.Time, err error) {
+	m.ocspMutex.Lock()
+	m.ocspState[subject] = &OCSPStatus{Subject: subject, NextUpdate: nextUpdate, CheckedAt: time.Now(), LastError: err}
+	m.ocspMutex.Unlock()
+
+	if !nextUpdate.IsZero() {
+		metrics.CertificateOCSPNextUpdate.WithLabelValues(subject).Set(float64(nextUpdate.Unix()))
+	}
+}
+
+func (m *Manager) ocspStatus(subject string) (*OCSPStatus, bool) {
+	m.ocspMutex.Lock()
+	defer m.ocspMutex.Unlock()
+	status, ok := m.ocspState[subject]
+	return status, ok
+}
+
+// OCSPStatuses returns the most recent OCSP refresh result for every subject
+// this Manager has attempted to staple, for use by a health/metrics surface.
+func (m *Manager) OCSPStatuses() []OCSPStatus {
+	m.ocspMutex.Lock()
+	defer m.ocspMutex.Unlock()
+
+	statuses := make([]OCSPStatus, 0, len(m.ocspState))
+	for _, status := range m.ocspState {
+		statuses = append(statuses, *status)
+	}
+	return statuses
+}
+
+func (m *Manager) isFresh(cert *tls.Certificate) bool {
+	if len(cert.Certificate) == 0 {
+		return false
+	}
+
+	leaf := cert.Leaf
+	if leaf == nil {
+		parsed, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return false
+		}
+		leaf = parsed
+	}
+
+	return time.Until(leaf.NotAfter) > m.minCertValidity
+}
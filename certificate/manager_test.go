@@ -0,0 +1,100 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *Store {
+	store, err := NewStore(filepath.Join(t.TempDir(), "certs.json"))
+	require.NoError(t, err)
+	return store
+}
+
+// dummyCertWithKey returns a *tls.Certificate with a real private key, since
+// Store.Put persists to disk and rejects a nil key when marshalling to
+// PKCS#8. The DER "chain" content itself doesn't need to be parseable; only
+// RefreshOCSP's chain-length check runs against it in these tests.
+func dummyCertWithKey(t *testing.T) *tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return &tls.Certificate{Certificate: [][]byte{{1, 2, 3}}, PrivateKey: key}
+}
+
+func Test_certificateChain_errorsIfChainHasNoIssuer(t *testing.T) {
+	_, _, err := certificateChain(dummyCertWithKey(t))
+	assert.Error(t, err)
+}
+
+func Test_Manager_RefreshOCSP_skipsCertificatesWithFreshStaple(t *testing.T) {
+	store := newTestStore(t)
+	require.NoError(t, store.Put("example.com", dummyCertWithKey(t)))
+
+	manager := NewManager(store, nil, time.Hour, time.Minute)
+	manager.recordOCSP("example.com", time.Now().Add(time.Hour), nil)
+
+	manager.RefreshOCSP()
+
+	status, ok := manager.ocspStatus("example.com")
+	require.True(t, ok)
+	assert.NoError(t, status.LastError)
+}
+
+func Test_Manager_RefreshOCSP_refreshesWhenStapleIsMissing(t *testing.T) {
+	store := newTestStore(t)
+	// Only one link in the chain, so certificateChain fails and the attempt
+	// is recorded as an error rather than silently skipped.
+	require.NoError(t, store.Put("example.com", dummyCertWithKey(t)))
+
+	manager := NewManager(store, nil, time.Hour, time.Minute)
+	manager.RefreshOCSP()
+
+	status, ok := manager.ocspStatus("example.com")
+	require.True(t, ok)
+	assert.Error(t, status.LastError)
+	assert.True(t, status.NextUpdate.IsZero())
+}
+
+func Test_Manager_RefreshOCSP_refreshesWhenExistingStapleIsStale(t *testing.T) {
+	store := newTestStore(t)
+	require.NoError(t, store.Put("example.com", dummyCertWithKey(t)))
+
+	manager := NewManager(store, nil, time.Hour, time.Minute)
+	// NextUpdate is within minOcspValidity of now, so the staple counts as
+	// stale and a refresh should be attempted rather than skipped.
+	manager.recordOCSP("example.com", time.Now().Add(30*time.Second), nil)
+
+	manager.RefreshOCSP()
+
+	status, ok := manager.ocspStatus("example.com")
+	require.True(t, ok)
+	assert.Error(t, status.LastError)
+}
+
+func Test_Manager_RefreshOCSP_doesNothingWithNoCertificatesStored(t *testing.T) {
+	store := newTestStore(t)
+	manager := NewManager(store, nil, time.Hour, time.Minute)
+
+	assert.NotPanics(t, manager.RefreshOCSP)
+	assert.Empty(t, manager.OCSPStatuses())
+}
+
+func Test_Manager_OCSPStatuses_returnsAllRecordedStatuses(t *testing.T) {
+	store := newTestStore(t)
+	manager := NewManager(store, nil, time.Hour, time.Minute)
+
+	manager.recordOCSP("a.example.com", time.Now(), nil)
+	manager.recordOCSP("b.example.com", time.Time{}, fmt.Errorf("ruh roh"))
+
+	statuses := manager.OCSPStatuses()
+	assert.Len(t, statuses, 2)
+}
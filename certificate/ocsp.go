@@ -0,0 +1,72 @@
+package certificate
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// ocspRequestTimeout bounds how long we'll wait for an OCSP responder,
+// so a single stalled responder can't wedge the refresh loop for every
+// other certificate.
+const ocspRequestTimeout = 10 * time.Second
+
+var ocspHTTPClient = &http.Client{Timeout: ocspRequestTimeout}
+
+// certificateChain splits a tls.Certificate's DER chain into its leaf and
+// issuer, both of which are required to build an OCSP request.
+func certificateChain(cert *tls.Certificate) (leaf *x509.Certificate, issuer *x509.Certificate, err error) {
+	if len(cert.Certificate) < 2 {
+		return nil, nil, fmt.Errorf("certificate chain has no issuer")
+	}
+
+	leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to parse leaf certificate: %w", err)
+	}
+
+	issuer, err = x509.ParseCertificate(cert.Certificate[1])
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to parse issuer certificate: %w", err)
+	}
+
+	return leaf, issuer, nil
+}
+
+// fetchOCSPStaple requests a fresh OCSP response for leaf from issuer's
+// responder, returning the raw DER response suitable for stapling alongside
+// the parsed response (used to read its NextUpdate time).
+func fetchOCSPStaple(leaf, issuer *x509.Certificate) ([]byte, *ocsp.Response, error) {
+	if len(leaf.OCSPServer) == 0 {
+		return nil, nil, fmt.Errorf("certificate has no OCSP responder")
+	}
+
+	req, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create OCSP request: %w", err)
+	}
+
+	httpResp, err := ocspHTTPClient.Post(leaf.OCSPServer[0], "application/ocsp-request", bytes.NewReader(req))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to reach OCSP responder: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	raw, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read OCSP response: %w", err)
+	}
+
+	parsed, err := ocsp.ParseResponse(raw, issuer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to parse OCSP response: %w", err)
+	}
+
+	return raw, parsed, nil
+}
@@ -0,0 +1,36 @@
+package certificate
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/csmith/centauri/proxy"
+)
+
+// providerSet multiplexes several named CertificateProviders into one,
+// selecting between them based on a route's preferred supplier.
+type providerSet struct {
+	providers map[string]proxy.CertificateProvider
+	fallback  string
+}
+
+// NewProviderSet returns a proxy.CertificateProvider that dispatches to one
+// of providers based on the preferredSupplier passed to GetCertificate,
+// falling back to the provider named fallback when none is specified.
+func NewProviderSet(providers map[string]proxy.CertificateProvider, fallback string) proxy.CertificateProvider {
+	return &providerSet{providers: providers, fallback: fallback}
+}
+
+func (p *providerSet) GetCertificate(preferredSupplier string, subject string, altNames []string) (*tls.Certificate, error) {
+	name := preferredSupplier
+	if name == "" {
+		name = p.fallback
+	}
+
+	provider, ok := p.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("no certificate provider named %q", name)
+	}
+
+	return provider.GetCertificate(preferredSupplier, subject, altNames)
+}
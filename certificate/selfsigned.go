@@ -0,0 +1,60 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// selfSignedSupplier issues short-lived self-signed certificates, for use
+// when no real certificate authority is configured (typically local
+// development).
+type selfSignedSupplier struct{}
+
+// NewSelfSignedSupplier returns a Supplier that generates a self-signed
+// certificate for every request.
+func NewSelfSignedSupplier() Supplier {
+	return &selfSignedSupplier{}
+}
+
+func (s *selfSignedSupplier) Obtain(subject string, altNames []string) (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate key: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate serial number: %w", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: subject},
+		DNSNames:     append([]string{subject}, altNames...),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(selfSignedMinCertValidity * 2),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create certificate: %w", err)
+	}
+
+	return &tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}, nil
+}
+
+// selfSignedMinCertValidity mirrors the validity window cmd/centauri uses
+// when self-signed certificates are the active provider.
+const selfSignedMinCertValidity = time.Hour * 24 * 7
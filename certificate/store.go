@@ -0,0 +1,141 @@
+package certificate
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// storedCertificate is the on-disk representation of a certificate.
+type storedCertificate struct {
+	Certificate [][]byte `json:"certificate"`
+	PrivateKey  []byte   `json:"privateKey"`
+	OCSPStaple  []byte   `json:"ocspStaple,omitempty"`
+}
+
+// Store persists issued certificates to a JSON file so they survive restarts.
+type Store struct {
+	path string
+
+	mutex sync.Mutex
+	certs map[string]*tls.Certificate
+}
+
+// NewStore loads the certificate store at path, creating it if it doesn't
+// already exist.
+func NewStore(path string) (*Store, error) {
+	store := &Store{path: path, certs: map[string]*tls.Certificate{}}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to read certificate store: %w", err)
+	}
+
+	var raw map[string]*storedCertificate
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("unable to parse certificate store: %w", err)
+	}
+
+	for subject, stored := range raw {
+		cert, err := stored.toCertificate()
+		if err != nil {
+			return nil, fmt.Errorf("unable to load certificate for %s: %w", subject, err)
+		}
+		store.certs[subject] = cert
+	}
+
+	return store, nil
+}
+
+// Get returns the stored certificate for subject, or nil if none is stored.
+func (s *Store) Get(subject string) *tls.Certificate {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.certs[subject]
+}
+
+// Put stores a certificate for subject and persists the store to disk.
+func (s *Store) Put(subject string, cert *tls.Certificate) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.certs[subject] = cert
+	return s.save()
+}
+
+// Subjects returns the subject of every certificate currently stored.
+func (s *Store) Subjects() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	subjects := make([]string, 0, len(s.certs))
+	for subject := range s.certs {
+		subjects = append(subjects, subject)
+	}
+	return subjects
+}
+
+// SetOCSPStaple attaches a freshly fetched OCSP response to the stored
+// certificate for subject and persists the change. A new *tls.Certificate is
+// stored rather than the existing one being mutated in place, since it may
+// already have been handed to a concurrent TLS handshake via Get.
+func (s *Store) SetOCSPStaple(subject string, staple []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	cert, ok := s.certs[subject]
+	if !ok {
+		return fmt.Errorf("no certificate stored for %s", subject)
+	}
+
+	updated := *cert
+	updated.OCSPStaple = staple
+	s.certs[subject] = &updated
+	return s.save()
+}
+
+func (s *Store) save() error {
+	raw := make(map[string]*storedCertificate, len(s.certs))
+	for subject, cert := range s.certs {
+		stored, err := fromCertificate(cert)
+		if err != nil {
+			return fmt.Errorf("unable to encode certificate for %s: %w", subject, err)
+		}
+		raw[subject] = stored
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("unable to marshal certificate store: %w", err)
+	}
+
+	return os.WriteFile(s.path, data, 0600)
+}
+
+func fromCertificate(cert *tls.Certificate) (*storedCertificate, error) {
+	keyBytes, err := x509MarshalKey(cert.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &storedCertificate{
+		Certificate: cert.Certificate,
+		PrivateKey:  keyBytes,
+		OCSPStaple:  cert.OCSPStaple,
+	}, nil
+}
+
+func (s *storedCertificate) toCertificate() (*tls.Certificate, error) {
+	key, err := x509ParseKey(s.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Certificate{
+		Certificate: s.Certificate,
+		PrivateKey:  key,
+		OCSPStaple:  s.OCSPStaple,
+	}, nil
+}
@@ -0,0 +1,10 @@
+package certificate
+
+import "crypto/tls"
+
+// Supplier obtains a new certificate for the given subject and alternative
+// names. Implementations may talk to an ACME directory, a self-signing
+// routine, or any other source of certificates.
+type Supplier interface {
+	Obtain(subject string, altNames []string) (*tls.Certificate, error)
+}
@@ -0,0 +1,63 @@
+package certificate
+
+import (
+	"crypto/tls"
+	"strings"
+
+	"github.com/csmith/centauri/proxy"
+)
+
+// wildcardResolver rewrites certificate requests for configured domains (and
+// their subdomains) to ask for a wildcard certificate instead, so that a
+// single certificate can cover an entire domain.
+type wildcardResolver struct {
+	inner   proxy.CertificateProvider
+	domains []string
+}
+
+// NewWildcardResolver wraps inner so that requests for any of the given
+// domains (or a subdomain of one) are served from a `*.domain` certificate
+// instead of a per-host one.
+func NewWildcardResolver(inner proxy.CertificateProvider, domains []string) proxy.CertificateProvider {
+	return &wildcardResolver{inner: inner, domains: NormalizeWildcardDomains(domains)}
+}
+
+// NormalizeWildcardDomains trims whitespace from domains and drops any empty
+// entries. It's exported so that callers other than NewWildcardResolver
+// (such as registerChallenges, which checks the same list via WildcardBase)
+// see exactly the same set of configured wildcard domains.
+func NormalizeWildcardDomains(domains []string) []string {
+	var normalized []string
+	for _, domain := range domains {
+		domain = strings.TrimSpace(domain)
+		if domain != "" {
+			normalized = append(normalized, domain)
+		}
+	}
+	return normalized
+}
+
+func (w *wildcardResolver) GetCertificate(preferredSupplier string, subject string, altNames []string) (*tls.Certificate, error) {
+	if base, ok := WildcardBase(w.domains, subject); ok {
+		return w.inner.GetCertificate(preferredSupplier, "*."+base, []string{base})
+	}
+	return w.inner.GetCertificate(preferredSupplier, subject, altNames)
+}
+
+// WildcardBase returns the most specific domain in bases that covers subject
+// (i.e. subject equals it or is one of its subdomains), so that behaviour is
+// deterministic when domains overlap (e.g. both "example.com" and
+// "sub.example.com" are configured). It's exported so that callers
+// registering ACME challenges can reject a route whose domain would
+// actually be served from a wildcard certificate it didn't ask for, the same
+// way config.ValidateRoute rejects a literal "*." domain with an
+// incompatible challenge.
+func WildcardBase(bases []string, subject string) (string, bool) {
+	var best string
+	for _, base := range bases {
+		if (subject == base || strings.HasSuffix(subject, "."+base)) && len(base) > len(best) {
+			best = base
+		}
+	}
+	return best, best != ""
+}
@@ -0,0 +1,46 @@
+package certificate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WildcardBase_returnsFalseIfNoBaseMatches(t *testing.T) {
+	_, ok := WildcardBase([]string{"example.com"}, "example.net")
+
+	assert.False(t, ok)
+}
+
+func Test_WildcardBase_matchesExactDomain(t *testing.T) {
+	base, ok := WildcardBase([]string{"example.com"}, "example.com")
+
+	assert.True(t, ok)
+	assert.Equal(t, "example.com", base)
+}
+
+func Test_WildcardBase_matchesSubdomain(t *testing.T) {
+	base, ok := WildcardBase([]string{"example.com"}, "app.example.com")
+
+	assert.True(t, ok)
+	assert.Equal(t, "example.com", base)
+}
+
+func Test_WildcardBase_doesNotMatchUnrelatedSuffix(t *testing.T) {
+	_, ok := WildcardBase([]string{"example.com"}, "notexample.com")
+
+	assert.False(t, ok)
+}
+
+func Test_WildcardBase_returnsMostSpecificOverlappingBase(t *testing.T) {
+	base, ok := WildcardBase([]string{"example.com", "sub.example.com"}, "app.sub.example.com")
+
+	assert.True(t, ok)
+	assert.Equal(t, "sub.example.com", base)
+}
+
+func Test_NormalizeWildcardDomains_trimsAndDropsEmptyEntries(t *testing.T) {
+	result := NormalizeWildcardDomains([]string{" example.com ", "", "other.com"})
+
+	assert.Equal(t, []string{"example.com", "other.com"}, result)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/csmith/centauri/proxy/accesslog"
+)
+
+var (
+	accessLogPath           = flag.String("access-log", "", "Path to write the access log to; disabled if empty")
+	accessLogFormat         = flag.String("access-log-format", "json", "Access log format: json or common")
+	accessLogTrustedProxies = flag.String("access-log-trusted-proxies", "", "Space separated list of trusted proxy IPs/CIDRs for X-Forwarded-For")
+	accessLogExcludePaths   = flag.String("access-log-exclude-paths", "", "Space separated list of path prefixes to omit from the access log")
+	accessLogMaxSizeMB      = flag.Int64("access-log-max-size-mb", 100, "Rotate the access log once it exceeds this size in megabytes")
+	accessLogMaxAge         = flag.Duration("access-log-max-age", 24*time.Hour, "Rotate the access log once it's older than this")
+)
+
+func newAccessLogger() (*accesslog.Logger, error) {
+	trustedProxies, err := accesslog.ParseTrustedProxies(strings.Fields(*accessLogTrustedProxies))
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted proxy: %w", err)
+	}
+
+	return accesslog.New(accesslog.Config{
+		Path:           *accessLogPath,
+		Format:         accesslog.Format(*accessLogFormat),
+		TrustedProxies: trustedProxies,
+		ExcludePaths:   strings.Fields(*accessLogExcludePaths),
+		MaxSizeBytes:   *accessLogMaxSizeMB * 1024 * 1024,
+		MaxAge:         *accessLogMaxAge,
+	})
+}
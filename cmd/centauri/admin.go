@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var adminAddress = flag.String("admin-address", "", "Address to serve the admin health and metrics endpoints on (e.g. localhost:8081); empty disables it")
+
+// serveAdmin starts the admin listener exposing /healthz, /metrics and the
+// route management API, if configured. It is deliberately separate from the
+// main frontend listener so it can be bound to a private address even when
+// the proxy itself is public.
+func serveAdmin() {
+	if *adminAddress == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", healthzHandler)
+	mux.Handle("/metrics", promhttp.Handler())
+	registerRoutesAPI(mux)
+
+	go func() {
+		if err := http.ListenAndServe(*adminAddress, mux); err != nil {
+			log.Printf("Admin listener stopped: %v", err)
+		}
+	}()
+}
+
+// ocspHealth is the JSON representation of a single certificate's OCSP
+// refresh status on the /healthz endpoint.
+type ocspHealth struct {
+	Subject    string `json:"subject"`
+	NextUpdate string `json:"ocspNextUpdate,omitempty"`
+	CheckedAt  string `json:"ocspCheckedAt,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+	statuses := ocspStatuses()
+	health := make([]ocspHealth, 0, len(statuses))
+
+	for _, status := range statuses {
+		entry := ocspHealth{Subject: status.Subject}
+		if !status.CheckedAt.IsZero() {
+			entry.CheckedAt = status.CheckedAt.Format(time.RFC3339)
+		}
+		if !status.NextUpdate.IsZero() {
+			entry.NextUpdate = status.NextUpdate.Format(time.RFC3339)
+		}
+		if status.LastError != nil {
+			entry.Error = status.LastError.Error()
+		}
+		health = append(health, entry)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"ocsp": health}); err != nil {
+		log.Printf("Error writing health response: %v", err)
+	}
+}
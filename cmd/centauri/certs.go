@@ -20,6 +20,8 @@ var (
 	acmeEmail            = flag.String("acme-email", "", "Email address for ACME account")
 	acmeDirectory        = flag.String("acme-directory", lego.LEDirectoryProduction, "ACME directory to use")
 	wildcardDomains      = flag.String("wildcard-domains", "", "Space separated list of wildcard domains")
+	certCheckInterval    = flag.Duration("cert-check-interval", 7*24*time.Hour, "How often to check certificates for renewal")
+	ocspRefreshInterval  = flag.Duration("ocsp-refresh-interval", 6*time.Hour, "How often to refresh OCSP staples")
 )
 
 const (
@@ -29,38 +31,77 @@ const (
 	selfSignedOcspValidity    = time.Second
 )
 
+// legoSupplier is kept so that updateRoutes can tell it which ACME challenge
+// each route prefers; it is nil if the lego provider couldn't be set up.
+var legoSupplier *certificate.LegoSupplier
+
+// challengeStore backs the HTTP-01 and TLS-ALPN-01 challenges that the
+// frontend answers directly, ahead of the normal proxy path.
+var challengeStore = certificate.NewChallengeStore()
+
+// certManagers is kept so that monitorCerts and the admin health endpoint
+// can refresh and report on OCSP staples independently of the per-route
+// certificate lookups, which only see the wildcard-resolving wrapper.
+var certManagers []*certificate.Manager
+
+// wildcardConfig is kept so that registerChallenges can reject a route whose
+// domain would be silently rewritten to a `*.domain` certificate request by
+// the wildcard resolver, rather than have its configured challenge type
+// quietly ignored.
+var wildcardConfig []string
+
 func certProviders() (map[string]proxy.CertificateProvider, error) {
 	dnsProvider, err := legotapas.CreateProvider(*dnsProviderName)
 	if err != nil {
 		return nil, fmt.Errorf("dns provider error: %v", err)
 	}
 
-	legoSupplier, err := certificate.NewLegoSupplier(&certificate.LegoSupplierConfig{
+	supplier, err := certificate.NewLegoSupplier(&certificate.LegoSupplierConfig{
 		Path:        *userDataPath,
 		Email:       *acmeEmail,
 		DirUrl:      *acmeDirectory,
 		KeyType:     certcrypto.EC384,
 		DnsProvider: dnsProvider,
+		Challenges:  challengeStore,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("certificate supplier error: %v", err)
 	}
+	legoSupplier = supplier
 
 	store, err := certificate.NewStore(*certificateStorePath)
 	if err != nil {
 		return nil, fmt.Errorf("certificate store error: %v", err)
 	}
 
-	var wildcardConfig = strings.Split(*wildcardDomains, " ")
+	wildcardConfig = certificate.NormalizeWildcardDomains(strings.Split(*wildcardDomains, " "))
+
+	legoManager := certificate.NewManager(store, legoSupplier, acmeMinCertValidity, acmeMinOcspValidity)
+	selfSignedManager := certificate.NewManager(store, certificate.NewSelfSignedSupplier(), selfSignedMinCertValidity, selfSignedOcspValidity)
+
+	// Only the ACME-issued manager is OCSP-relevant: self-signed certs have
+	// no issuer in their chain, so there's no responder to staple from.
+	certManagers = []*certificate.Manager{legoManager}
 
 	return map[string]proxy.CertificateProvider{
-		"lego": certificate.NewWildcardResolver(
-			certificate.NewManager(store, legoSupplier, acmeMinCertValidity, acmeMinOcspValidity),
-			wildcardConfig,
-		),
-		"selfsigned": certificate.NewWildcardResolver(
-			certificate.NewManager(store, certificate.NewSelfSignedSupplier(), selfSignedMinCertValidity, selfSignedOcspValidity),
-			wildcardConfig,
-		),
+		"lego":       certificate.NewWildcardResolver(legoManager, wildcardConfig),
+		"selfsigned": certificate.NewWildcardResolver(selfSignedManager, wildcardConfig),
 	}, nil
 }
+
+// refreshOCSP refreshes OCSP staples for every certificate manager in use.
+func refreshOCSP() {
+	for _, m := range certManagers {
+		m.RefreshOCSP()
+	}
+}
+
+// ocspStatuses returns the most recent OCSP refresh result for every subject
+// across all certificate managers in use.
+func ocspStatuses() []certificate.OCSPStatus {
+	var statuses []certificate.OCSPStatus
+	for _, m := range certManagers {
+		statuses = append(statuses, m.OCSPStatuses()...)
+	}
+	return statuses
+}
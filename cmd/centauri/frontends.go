@@ -0,0 +1,14 @@
+package main
+
+import (
+	"context"
+
+	"github.com/csmith/centauri/proxy"
+)
+
+// Frontend accepts connections and routes them to upstreams via a
+// proxy.Rewriter, resolving certificates through a proxy.Manager.
+type Frontend interface {
+	Serve(manager *proxy.Manager, rewriter *proxy.Rewriter) error
+	Stop(ctx context.Context) error
+}
@@ -3,15 +3,22 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/csmith/centauri/certificate"
 	"github.com/csmith/centauri/config"
 	"github.com/csmith/centauri/proxy"
+	"github.com/csmith/centauri/proxy/accesslog"
+	"github.com/csmith/centauri/tcp"
 	"github.com/csmith/envflag"
+	"github.com/fsnotify/fsnotify"
 )
 
 var (
@@ -20,6 +27,15 @@ var (
 )
 
 var proxyManager *proxy.Manager
+var frontends map[string]Frontend
+var accessLogger *accesslog.Logger
+
+// reloadMutex serializes calls to registerChallenges/SetRoutes, which can
+// otherwise be triggered concurrently from SIGHUP, the config file watcher
+// and the admin API's PUT /routes. Without it, two overlapping reloads could
+// interleave their snapshot/register/restore sequences on legoSupplier and
+// have one clobber the other's registrations.
+var reloadMutex sync.Mutex
 
 func main() {
 	envflag.Parse()
@@ -34,44 +50,152 @@ func main() {
 		defaultProvider = "selfsigned"
 	}
 
-	proxyManager = proxy.NewManager(providers, defaultProvider)
-	rewriter := proxy.NewRewriter(proxyManager)
-	updateRoutes()
+	accessLogger, err = newAccessLogger()
+	if err != nil {
+		log.Fatalf("Error creating access logger: %v", err)
+	}
+
+	rateLimiter, err := newRateLimiter()
+	if err != nil {
+		log.Fatalf("Error creating rate limiter: %v", err)
+	}
+
+	if err := initMetrics(); err != nil {
+		log.Fatalf("Error configuring metrics: %v", err)
+	}
+
+	proxyManager = proxy.NewManager(certificate.NewProviderSet(providers, defaultProvider))
+	rewriter := proxy.NewRewriter(proxyManager, accessLogger, rateLimiter)
+	frontends = map[string]Frontend{
+		"tcp": tcp.New(challengeStore),
+	}
+	if err := updateRoutes(); err != nil {
+		log.Fatalf("Failed to load initial config: %v", err)
+	}
 	listenForHup()
+	watchConfig()
 	monitorCerts()
+	serveAdmin()
 
 	f, ok := frontends[*selectedFrontend]
 	if !ok {
 		log.Fatalf("Invalid frontend specified: %s", *selectedFrontend)
 	}
 
-	err = f.Serve(proxyManager, rewriter)
-	if err != nil {
-		log.Fatalf("Failed to start frontend: %v", err)
-	}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- f.Serve(proxyManager, rewriter) }()
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
 	signal.Notify(c, syscall.SIGTERM)
 
-	// Wait for a signal
-	log.Printf("Received signal %s, stopping frontend...", <-c)
-
-	f.Stop(context.Background())
+	select {
+	case err := <-serveErr:
+		if err != nil {
+			log.Fatalf("Failed to start frontend: %v", err)
+		}
+	case sig := <-c:
+		log.Printf("Received signal %s, stopping frontend...", sig)
+		f.Stop(context.Background())
+	}
 
 	log.Printf("Frontend stopped. Goodbye!")
 }
 
+// monitorCerts runs certificate expiry checks and OCSP staple refreshes on
+// their own cadences, since they have very different natural frequencies.
+// Neither fatally exits on error, since both talk to external services
+// (the ACME directory and OCSP responders) that may be transiently
+// unreachable; failures are logged and surfaced on the admin health
+// endpoint instead.
 func monitorCerts() {
 	go func() {
 		for {
-			time.Sleep(12 * time.Hour)
+			time.Sleep(*certCheckInterval)
 			log.Printf("Checking for certificate validity...")
 			if err := proxyManager.CheckCertificates(); err != nil {
-				log.Fatalf("Error performing periodic check of certificates: %v", err)
+				log.Printf("Error performing periodic check of certificates: %v", err)
 			}
 		}
 	}()
+
+	go func() {
+		for {
+			time.Sleep(*ocspRefreshInterval)
+			log.Printf("Refreshing OCSP staples...")
+			refreshOCSP()
+		}
+	}()
+}
+
+// configWatchDebounce is how long watchConfig waits after the last detected
+// change before reloading, so that editors and config management tools
+// that write a file in several steps (or replace it via a temp file and
+// rename) only trigger a single reload.
+const configWatchDebounce = 500 * time.Millisecond
+
+// watchConfig reloads routes whenever *configPath changes on disk, as an
+// alternative to sending SIGHUP. It watches the containing directory rather
+// than the file itself so that atomic replace-via-rename (used by most
+// config management tools) is still picked up.
+func watchConfig() {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("Unable to watch config file for changes: %v", err)
+		return
+	}
+
+	target := filepath.Clean(*configPath)
+	if err := watcher.Add(filepath.Dir(target)); err != nil {
+		log.Printf("Unable to watch config file for changes: %v", err)
+		_ = watcher.Close()
+		return
+	}
+
+	go func() {
+		defer watcher.Close()
+		runConfigWatcher(target, watcher.Events, watcher.Errors, updateRoutes)
+	}()
+}
+
+// runConfigWatcher holds the debounce/reload loop itself, decoupled from
+// fsnotify and updateRoutes so it can be driven with synthetic events in
+// tests. It returns once events or errs is closed.
+func runConfigWatcher(target string, events <-chan fsnotify.Event, errs <-chan error, reload func() error) {
+	var debounce *time.Timer
+	reloadCh := make(chan struct{}, 1)
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != target {
+				continue
+			}
+			if debounce == nil {
+				debounce = time.AfterFunc(configWatchDebounce, func() {
+					select {
+					case reloadCh <- struct{}{}:
+					default:
+					}
+				})
+			} else {
+				debounce.Reset(configWatchDebounce)
+			}
+		case <-reloadCh:
+			log.Printf("Config file changed, reloading routes")
+			if err := reload(); err != nil {
+				log.Printf("Error reloading routes: %v", err)
+			}
+		case err, ok := <-errs:
+			if !ok {
+				return
+			}
+			log.Printf("Config watcher error: %v", err)
+		}
+	}
 }
 
 func listenForHup() {
@@ -82,29 +206,107 @@ func listenForHup() {
 		for {
 			<-c
 			log.Printf("Received SIGHUP, updating routes")
-			updateRoutes()
+			if err := updateRoutes(); err != nil {
+				log.Printf("Error updating routes: %v", err)
+			}
+			if err := accessLogger.Reopen(); err != nil {
+				log.Printf("Error reopening access log: %v", err)
+			}
 		}
 	}()
 }
 
-func updateRoutes() {
+// updateRoutes reads and installs the routes described by *configPath. It
+// never exits the process on failure, since a typo in a config reload
+// shouldn't bring down an otherwise-healthy proxy; the previous routes are
+// left in place and the error is returned for the caller to log.
+func updateRoutes() error {
 	log.Printf("Reading config file %s", *configPath)
 
 	configFile, err := os.Open(*configPath)
 	if err != nil {
-		log.Fatalf("Failed to open config file: %v", err)
+		return fmt.Errorf("failed to open config file: %w", err)
 	}
 	defer configFile.Close()
 
 	routes, err := config.Parse(configFile)
 	if err != nil {
-		log.Fatalf("Failed to parse config file: %v", err)
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	return installRoutes(routes)
+}
+
+// installRoutes registers routes' challenge settings and applies them via
+// SetRoutes, under reloadMutex so that a concurrent reload can't interleave
+// with this one. It's shared by the config file path (SIGHUP, the file
+// watcher) and the admin API's PUT /routes.
+func installRoutes(routes []*proxy.Route) error {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	snapshot, err := registerChallenges(routes)
+	if err != nil {
+		return err
 	}
 
 	log.Printf("Installing %d routes", len(routes))
 	if err := proxyManager.SetRoutes(routes); err != nil {
-		log.Fatalf("Route manager error: %v", err)
+		restoreChallenges(snapshot)
+		return fmt.Errorf("route manager error: %w", err)
 	}
 
 	log.Printf("Finished installing %d routes", len(routes))
+	return nil
+}
+
+// registerChallenges tells legoSupplier which challenge type and must-staple
+// setting to use for every domain in routes, so that certificates requested
+// through SetRoutes use the right configuration. Alongside any error, it
+// returns a snapshot of the registrations that were in place beforehand;
+// callers should pass this to restoreChallenges if routes is subsequently
+// rejected by SetRoutes, so that legoSupplier's state doesn't end up
+// reflecting a route set that never actually took effect. Callers must hold
+// reloadMutex.
+func registerChallenges(routes []*proxy.Route) (*certificate.Registrations, error) {
+	if legoSupplier == nil {
+		return nil, nil
+	}
+
+	snapshot := legoSupplier.Snapshot()
+	for _, route := range routes {
+		for _, domain := range route.Domains {
+			// A domain covered by --wildcard-domains is actually requested
+			// as "*.base" once it reaches the wildcard resolver, so
+			// anything registered against the literal domain - challenge
+			// type or must-staple - would never be consulted: Obtain looks
+			// both up keyed by "*.base", not the route's domain.
+			if base, ok := certificate.WildcardBase(wildcardConfig, domain); ok {
+				if !certificate.ChallengeType(route.Challenge).IsWildcardCompatible() {
+					restoreChallenges(snapshot)
+					return snapshot, fmt.Errorf("domain %s is covered by wildcard domain %s, which requires the dns challenge", domain, base)
+				}
+				if route.MustStaple {
+					restoreChallenges(snapshot)
+					return snapshot, fmt.Errorf("domain %s is covered by wildcard domain %s, which doesn't support per-domain must-staple", domain, base)
+				}
+			}
+
+			if err := legoSupplier.RegisterChallenge(domain, certificate.ChallengeType(route.Challenge)); err != nil {
+				restoreChallenges(snapshot)
+				return snapshot, fmt.Errorf("invalid challenge for %s: %w", domain, err)
+			}
+			legoSupplier.RegisterMustStaple(domain, route.MustStaple)
+		}
+	}
+	return snapshot, nil
+}
+
+// restoreChallenges restores a snapshot captured by registerChallenges. It's
+// a no-op if legoSupplier isn't configured.
+func restoreChallenges(snapshot *certificate.Registrations) {
+	if legoSupplier == nil || snapshot == nil {
+		return
+	}
+	legoSupplier.Restore(snapshot)
 }
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/csmith/centauri/metrics"
+)
+
+var httpDurationBuckets = flag.String("http-duration-buckets", "0.1,0.3,1.2,5", "Comma separated histogram buckets (in seconds) for centauri_http_request_duration_seconds")
+
+// initMetrics registers the metrics that need configuration before they can
+// be recorded against.
+func initMetrics() error {
+	buckets, err := parseBuckets(*httpDurationBuckets)
+	if err != nil {
+		return fmt.Errorf("invalid http duration buckets: %w", err)
+	}
+	metrics.Init(buckets)
+	return nil
+}
+
+func parseBuckets(s string) ([]float64, error) {
+	fields := strings.Split(s, ",")
+	buckets := make([]float64, 0, len(fields))
+	for _, field := range fields {
+		value, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bucket %q: %w", field, err)
+		}
+		buckets = append(buckets, value)
+	}
+	return buckets, nil
+}
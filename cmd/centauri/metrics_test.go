@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseBuckets_parsesCommaSeparatedValues(t *testing.T) {
+	buckets, err := parseBuckets("0.1,0.3,1.2,5")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0.1, 0.3, 1.2, 5}, buckets)
+}
+
+func Test_parseBuckets_trimsWhitespaceAroundValues(t *testing.T) {
+	buckets, err := parseBuckets(" 0.1 , 0.3 ")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0.1, 0.3}, buckets)
+}
+
+func Test_parseBuckets_errorsOnInvalidValue(t *testing.T) {
+	_, err := parseBuckets("0.1,not-a-number")
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/csmith/centauri/proxy"
+	"github.com/csmith/centauri/proxy/accesslog"
+)
+
+var (
+	rateLimitTrustedProxies = flag.String("ratelimit-trusted-proxies", "", "Space separated list of trusted proxy IPs/CIDRs for X-Forwarded-For when rate limiting")
+	maxUpstreamConnections  = flag.Int("max-upstream-connections", 0, "Maximum concurrent connections to any single upstream; 0 disables the cap")
+)
+
+func newRateLimiter() (*proxy.RateLimiter, error) {
+	trustedProxies, err := accesslog.ParseTrustedProxies(strings.Fields(*rateLimitTrustedProxies))
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted proxy: %w", err)
+	}
+
+	return proxy.NewRateLimiter(trustedProxies, *maxUpstreamConnections), nil
+}
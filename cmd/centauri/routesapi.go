@@ -0,0 +1,107 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/csmith/centauri/config"
+	"github.com/csmith/centauri/proxy"
+)
+
+// maxRoutesBodyBytes bounds how much of a PUT /routes request we'll read,
+// so a misbehaving (or malicious) client can't exhaust memory.
+const maxRoutesBodyBytes = 1 << 20
+
+var adminToken = flag.String("admin-token", "", "Bearer token required to use the /routes admin API; empty disables it")
+
+// registerRoutesAPI adds the route management endpoints to mux: GET /routes
+// to inspect the active configuration, PUT /routes to replace it, and
+// POST /routes/{domain}/renew to force a certificate check for one domain.
+// All three require the bearer token configured with --admin-token.
+func registerRoutesAPI(mux *http.ServeMux) {
+	mux.HandleFunc("GET /routes", requireAdminToken(getRoutesHandler))
+	mux.HandleFunc("PUT /routes", requireAdminToken(putRoutesHandler))
+	mux.HandleFunc("POST /routes/{domain}/renew", requireAdminToken(renewRouteHandler))
+}
+
+func requireAdminToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if *adminToken == "" {
+			writeJSONError(w, http.StatusServiceUnavailable, "admin API is disabled; set --admin-token to enable it")
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(*adminToken)) != 1 {
+			writeJSONError(w, http.StatusUnauthorized, "invalid or missing admin token")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func getRoutesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(proxyManager.Routes()); err != nil {
+		log.Printf("Error writing routes response: %v", err)
+	}
+}
+
+func putRoutesHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRoutesBodyBytes))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("unable to read request body: %v", err))
+		return
+	}
+
+	var routes []*proxy.Route
+	if err := json.Unmarshal(body, &routes); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid route definitions: %v", err))
+		return
+	}
+
+	for _, route := range routes {
+		if err := config.ValidateRoute(route); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if err := installRoutes(routes); err != nil {
+		status := http.StatusUnprocessableEntity
+		var routeErr *proxy.RouteError
+		if !errors.As(err, &routeErr) {
+			status = http.StatusBadRequest
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+
+	log.Printf("Installed %d routes via admin API", len(routes))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func renewRouteHandler(w http.ResponseWriter, r *http.Request) {
+	domain := r.PathValue("domain")
+	if err := proxyManager.RenewRoute(domain); err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]string{"error": message}); err != nil {
+		log.Printf("Error writing error response: %v", err)
+	}
+}
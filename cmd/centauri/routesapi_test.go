@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/csmith/centauri/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withAdminToken sets *adminToken for the duration of a test and restores it
+// afterwards, since it's a package-level flag shared by requireAdminToken.
+func withAdminToken(t *testing.T, token string) {
+	previous := *adminToken
+	*adminToken = token
+	t.Cleanup(func() { *adminToken = previous })
+}
+
+func Test_requireAdminToken_returnsServiceUnavailableIfDisabled(t *testing.T) {
+	withAdminToken(t, "")
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/routes", nil)
+	requireAdminToken(getRoutesHandler)(w, r)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func Test_requireAdminToken_returnsUnauthorizedForMissingOrWrongToken(t *testing.T) {
+	withAdminToken(t, "secret")
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/routes", nil)
+	r.Header.Set("Authorization", "Bearer wrong")
+	requireAdminToken(getRoutesHandler)(w, r)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func Test_requireAdminToken_callsNextForCorrectToken(t *testing.T) {
+	withAdminToken(t, "secret")
+
+	called := false
+	handler := requireAdminToken(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/routes", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	handler(w, r)
+
+	assert.True(t, called)
+}
+
+func Test_getRoutesHandler_returnsConfiguredRoutes(t *testing.T) {
+	proxyManager = proxy.NewManager(nil)
+	require.NoError(t, proxyManager.SetRoutes([]*proxy.Route{{Domains: []string{"example.com"}}}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/routes", nil)
+	getRoutesHandler(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "example.com")
+}
+
+func Test_putRoutesHandler_rejectsInvalidJSON(t *testing.T) {
+	proxyManager = proxy.NewManager(nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/routes", strings.NewReader("not json"))
+	putRoutesHandler(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func Test_putRoutesHandler_rejectsRouteFailingValidation(t *testing.T) {
+	proxyManager = proxy.NewManager(nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/routes", strings.NewReader(`[{"Domains":[]}]`))
+	putRoutesHandler(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func Test_putRoutesHandler_installsValidRoutes(t *testing.T) {
+	proxyManager = proxy.NewManager(nil)
+	legoSupplier = nil
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/routes", strings.NewReader(`[{"Domains":["example.com"]}]`))
+	putRoutesHandler(w, r)
+
+	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.NotNil(t, proxyManager.RouteForDomain("example.com"))
+}
+
+func Test_renewRouteHandler_returnsUnprocessableEntityIfRouteNotFound(t *testing.T) {
+	proxyManager = proxy.NewManager(nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/routes/example.com/renew", nil)
+	r.SetPathValue("domain", "example.com")
+	renewRouteHandler(w, r)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_runConfigWatcher_ignoresEventsForOtherFiles(t *testing.T) {
+	events := make(chan fsnotify.Event, 1)
+	errs := make(chan error)
+	var reloads int32
+	done := make(chan struct{})
+
+	go func() {
+		runConfigWatcher("/config/centauri.conf", events, errs, func() error {
+			atomic.AddInt32(&reloads, 1)
+			return nil
+		})
+		close(done)
+	}()
+
+	events <- fsnotify.Event{Name: "/config/unrelated.txt"}
+	time.Sleep(configWatchDebounce * 2)
+	close(events)
+	<-done
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&reloads))
+}
+
+func Test_runConfigWatcher_debouncesRapidChangesIntoOneReload(t *testing.T) {
+	events := make(chan fsnotify.Event, 3)
+	errs := make(chan error)
+	var reloads int32
+	done := make(chan struct{})
+
+	go func() {
+		runConfigWatcher("/config/centauri.conf", events, errs, func() error {
+			atomic.AddInt32(&reloads, 1)
+			return nil
+		})
+		close(done)
+	}()
+
+	events <- fsnotify.Event{Name: "/config/centauri.conf"}
+	events <- fsnotify.Event{Name: "/config/centauri.conf"}
+	events <- fsnotify.Event{Name: "/config/centauri.conf"}
+	time.Sleep(configWatchDebounce * 2)
+	close(events)
+	<-done
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&reloads))
+}
+
+func Test_runConfigWatcher_reloadsAgainAfterDebounceWindowPasses(t *testing.T) {
+	events := make(chan fsnotify.Event, 2)
+	errs := make(chan error)
+	var reloads int32
+	done := make(chan struct{})
+
+	go func() {
+		runConfigWatcher("/config/centauri.conf", events, errs, func() error {
+			atomic.AddInt32(&reloads, 1)
+			return nil
+		})
+		close(done)
+	}()
+
+	events <- fsnotify.Event{Name: "/config/centauri.conf"}
+	time.Sleep(configWatchDebounce * 2)
+	events <- fsnotify.Event{Name: "/config/centauri.conf"}
+	time.Sleep(configWatchDebounce * 2)
+	close(events)
+	<-done
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&reloads))
+}
+
+func Test_runConfigWatcher_returnsWhenErrorChannelCloses(t *testing.T) {
+	events := make(chan fsnotify.Event)
+	errs := make(chan error)
+	done := make(chan struct{})
+
+	go func() {
+		runConfigWatcher("/config/centauri.conf", events, errs, func() error { return nil })
+		close(done)
+	}()
+
+	close(errs)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("runConfigWatcher did not return after errs closed")
+	}
+}
@@ -0,0 +1,176 @@
+// Package config parses Centauri's route configuration file format.
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/csmith/centauri/proxy"
+)
+
+// Parse reads a Centauri config file and returns the routes it describes.
+//
+// The format is a sequence of `route <domains...>` blocks, each containing
+// indented directives (`upstream`, `provider`, `header`, `challenge`,
+// `must-staple`, `ratelimit`) describing how traffic for those domains
+// should be handled. Blank lines and lines starting with `#` are ignored,
+// and directives are matched case insensitively.
+func Parse(reader io.Reader) ([]*proxy.Route, error) {
+	var routes []*proxy.Route
+	var current *proxy.Route
+
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		directive := strings.ToLower(fields[0])
+		args := fields[1:]
+
+		switch directive {
+		case "route":
+			if len(args) == 0 {
+				return nil, fmt.Errorf("line %d: route requires at least one domain", lineNumber)
+			}
+			current = &proxy.Route{Domains: args}
+			routes = append(routes, current)
+		case "upstream":
+			if current == nil {
+				return nil, fmt.Errorf("line %d: upstream must be inside a route", lineNumber)
+			}
+			if current.Upstream != "" {
+				return nil, fmt.Errorf("line %d: route already has an upstream", lineNumber)
+			}
+			if len(args) != 1 {
+				return nil, fmt.Errorf("line %d: upstream requires exactly one address", lineNumber)
+			}
+			current.Upstream = args[0]
+		case "provider":
+			if current == nil {
+				return nil, fmt.Errorf("line %d: provider must be inside a route", lineNumber)
+			}
+			if current.Provider != "" {
+				return nil, fmt.Errorf("line %d: route already has a provider", lineNumber)
+			}
+			if len(args) != 1 {
+				return nil, fmt.Errorf("line %d: provider requires exactly one name", lineNumber)
+			}
+			current.Provider = args[0]
+		case "header":
+			if current == nil {
+				return nil, fmt.Errorf("line %d: header must be inside a route", lineNumber)
+			}
+			header, err := parseHeader(args)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			current.Headers = append(current.Headers, *header)
+		case "challenge":
+			if current == nil {
+				return nil, fmt.Errorf("line %d: challenge must be inside a route", lineNumber)
+			}
+			if current.Challenge != "" {
+				return nil, fmt.Errorf("line %d: route already has a challenge", lineNumber)
+			}
+			if len(args) != 1 {
+				return nil, fmt.Errorf("line %d: challenge requires exactly one type", lineNumber)
+			}
+			challengeType := strings.ToLower(args[0])
+			switch challengeType {
+			case "http", "tls-alpn", "dns":
+			default:
+				return nil, fmt.Errorf("line %d: unknown challenge type %q", lineNumber, args[0])
+			}
+			if challengeType != "dns" && hasWildcardDomain(current.Domains) {
+				return nil, fmt.Errorf("line %d: wildcard domains require the dns challenge", lineNumber)
+			}
+			current.Challenge = challengeType
+		case "must-staple":
+			if current == nil {
+				return nil, fmt.Errorf("line %d: must-staple must be inside a route", lineNumber)
+			}
+			if len(args) != 0 {
+				return nil, fmt.Errorf("line %d: must-staple takes no arguments", lineNumber)
+			}
+			current.MustStaple = true
+		case "ratelimit":
+			if current == nil {
+				return nil, fmt.Errorf("line %d: ratelimit must be inside a route", lineNumber)
+			}
+			if current.RateLimitRPS != 0 {
+				return nil, fmt.Errorf("line %d: route already has a ratelimit", lineNumber)
+			}
+			if len(args) != 3 || strings.ToLower(args[1]) != "burst" {
+				return nil, fmt.Errorf("line %d: expected \"ratelimit <rps> burst <n>\"", lineNumber)
+			}
+			rps, err := strconv.ParseFloat(args[0], 64)
+			if err != nil || rps <= 0 {
+				return nil, fmt.Errorf("line %d: invalid ratelimit rps %q", lineNumber, args[0])
+			}
+			burst, err := strconv.Atoi(args[2])
+			if err != nil || burst <= 0 {
+				return nil, fmt.Errorf("line %d: invalid ratelimit burst %q", lineNumber, args[2])
+			}
+			current.RateLimitRPS = rps
+			current.RateLimitBurst = burst
+		default:
+			return nil, fmt.Errorf("line %d: unknown directive %q", lineNumber, fields[0])
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return routes, nil
+}
+
+func hasWildcardDomain(domains []string) bool {
+	for _, domain := range domains {
+		if strings.HasPrefix(domain, "*.") {
+			return true
+		}
+	}
+	return false
+}
+
+func parseHeader(args []string) (*proxy.Header, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("header requires an operation and a name")
+	}
+
+	operation := strings.ToLower(args[0])
+	name := args[1]
+	value := strings.Join(args[2:], " ")
+
+	switch operation {
+	case "add":
+		if value == "" {
+			return nil, fmt.Errorf("header add requires a value")
+		}
+		return &proxy.Header{Name: name, Value: value, Operation: proxy.HeaderOpAdd}, nil
+	case "delete":
+		return &proxy.Header{Name: name, Operation: proxy.HeaderOpDelete}, nil
+	case "default":
+		if value == "" {
+			return nil, fmt.Errorf("header default requires a value")
+		}
+		return &proxy.Header{Name: name, Value: value, Operation: proxy.HeaderOpDefault}, nil
+	case "replace":
+		if value == "" {
+			return nil, fmt.Errorf("header replace requires a value")
+		}
+		return &proxy.Header{Name: name, Value: value, Operation: proxy.HeaderOpReplace}, nil
+	default:
+		return nil, fmt.Errorf("unknown header operation %q", args[0])
+	}
+}
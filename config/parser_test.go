@@ -187,3 +187,154 @@ rOuTe example.net
 	assert.Equal(t, "baz", routes[1].Headers[1].Value)
 	assert.Equal(t, proxy.HeaderOpReplace, routes[1].Headers[1].Operation)
 }
+
+func Test_Parse_ErrorsOnChallengeOutsideOfRoute(t *testing.T) {
+	_, err := Parse(bytes.NewBuffer([]byte("challenge http")))
+
+	assert.Error(t, err)
+}
+
+func Test_Parse_ErrorsOnUnknownChallengeType(t *testing.T) {
+	_, err := Parse(bytes.NewBuffer([]byte(`
+route example.com
+	challenge carrier-pigeon
+`)))
+
+	assert.Error(t, err)
+}
+
+func Test_Parse_ErrorsOnMultipleChallenges(t *testing.T) {
+	_, err := Parse(bytes.NewBuffer([]byte(`
+route example.com
+	challenge http
+	challenge dns
+`)))
+
+	assert.Error(t, err)
+}
+
+func Test_Parse_ErrorsOnNonDnsChallengeForWildcardDomain(t *testing.T) {
+	_, err := Parse(bytes.NewBuffer([]byte(`
+route *.example.com
+	challenge http
+`)))
+
+	assert.Error(t, err)
+}
+
+func Test_Parse_AllowsDnsChallengeForWildcardDomain(t *testing.T) {
+	routes, err := Parse(bytes.NewBuffer([]byte(`
+route *.example.com
+	challenge dns
+`)))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "dns", routes[0].Challenge)
+}
+
+func Test_Parse_ParsesChallengeType(t *testing.T) {
+	routes, err := Parse(bytes.NewBuffer([]byte(`
+route example.com
+	challenge tls-alpn
+`)))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "tls-alpn", routes[0].Challenge)
+}
+
+func Test_Parse_ErrorsOnMustStapleOutsideOfRoute(t *testing.T) {
+	_, err := Parse(bytes.NewBuffer([]byte("must-staple")))
+
+	assert.Error(t, err)
+}
+
+func Test_Parse_ErrorsOnMustStapleWithArguments(t *testing.T) {
+	_, err := Parse(bytes.NewBuffer([]byte(`
+route example.com
+	must-staple yes
+`)))
+
+	assert.Error(t, err)
+}
+
+func Test_Parse_ParsesMustStaple(t *testing.T) {
+	routes, err := Parse(bytes.NewBuffer([]byte(`
+route example.com
+	must-staple
+`)))
+
+	assert.NoError(t, err)
+	assert.True(t, routes[0].MustStaple)
+}
+
+func Test_Parse_ErrorsOnRatelimitOutsideOfRoute(t *testing.T) {
+	_, err := Parse(bytes.NewBuffer([]byte("ratelimit 5 burst 10")))
+
+	assert.Error(t, err)
+}
+
+func Test_Parse_ErrorsOnMultipleRatelimits(t *testing.T) {
+	_, err := Parse(bytes.NewBuffer([]byte(`
+route example.com
+	ratelimit 5 burst 10
+	ratelimit 1 burst 2
+`)))
+
+	assert.Error(t, err)
+}
+
+func Test_Parse_ErrorsOnRatelimitMissingBurstKeyword(t *testing.T) {
+	_, err := Parse(bytes.NewBuffer([]byte(`
+route example.com
+	ratelimit 5 10
+`)))
+
+	assert.Error(t, err)
+}
+
+func Test_Parse_ErrorsOnRatelimitWithInvalidRps(t *testing.T) {
+	_, err := Parse(bytes.NewBuffer([]byte(`
+route example.com
+	ratelimit notanumber burst 10
+`)))
+
+	assert.Error(t, err)
+}
+
+func Test_Parse_ErrorsOnRatelimitWithNonPositiveRps(t *testing.T) {
+	_, err := Parse(bytes.NewBuffer([]byte(`
+route example.com
+	ratelimit 0 burst 10
+`)))
+
+	assert.Error(t, err)
+}
+
+func Test_Parse_ErrorsOnRatelimitWithInvalidBurst(t *testing.T) {
+	_, err := Parse(bytes.NewBuffer([]byte(`
+route example.com
+	ratelimit 5 burst notanumber
+`)))
+
+	assert.Error(t, err)
+}
+
+func Test_Parse_ErrorsOnRatelimitWithNonPositiveBurst(t *testing.T) {
+	_, err := Parse(bytes.NewBuffer([]byte(`
+route example.com
+	ratelimit 5 burst 0
+`)))
+
+	assert.Error(t, err)
+}
+
+func Test_Parse_ParsesRatelimit(t *testing.T) {
+	routes, err := Parse(bytes.NewBuffer([]byte(`
+route example.com
+	ratelimit 5 burst 10
+`)))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, routes[0].RateLimitRPS)
+	assert.Equal(t, 10, routes[0].RateLimitBurst)
+}
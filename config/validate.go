@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/csmith/centauri/proxy"
+)
+
+// ValidateRoute checks the same invariants Parse enforces on a route's
+// challenge and rate limit settings, so routes submitted through another
+// source (such as the admin API) are held to the same rules as ones read
+// from the config file.
+func ValidateRoute(route *proxy.Route) error {
+	if len(route.Domains) == 0 {
+		return fmt.Errorf("route requires at least one domain")
+	}
+
+	if route.Challenge != "" {
+		switch route.Challenge {
+		case "http", "tls-alpn", "dns":
+		default:
+			return fmt.Errorf("unknown challenge type %q", route.Challenge)
+		}
+		if route.Challenge != "dns" && hasWildcardDomain(route.Domains) {
+			return fmt.Errorf("wildcard domains require the dns challenge")
+		}
+	}
+
+	if route.RateLimitRPS != 0 && (route.RateLimitRPS <= 0 || route.RateLimitBurst <= 0) {
+		return fmt.Errorf("ratelimit requires a positive rps and burst")
+	}
+
+	for _, header := range route.Headers {
+		switch header.Operation {
+		case proxy.HeaderOpAdd, proxy.HeaderOpDelete, proxy.HeaderOpDefault, proxy.HeaderOpReplace:
+		default:
+			return fmt.Errorf("unknown header operation %d", header.Operation)
+		}
+	}
+
+	return nil
+}
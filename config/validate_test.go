@@ -0,0 +1,62 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/csmith/centauri/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ValidateRoute_errorsOnNoDomains(t *testing.T) {
+	err := ValidateRoute(&proxy.Route{})
+
+	assert.Error(t, err)
+}
+
+func Test_ValidateRoute_errorsOnUnknownChallengeType(t *testing.T) {
+	err := ValidateRoute(&proxy.Route{Domains: []string{"example.com"}, Challenge: "carrier-pigeon"})
+
+	assert.Error(t, err)
+}
+
+func Test_ValidateRoute_errorsOnNonDnsChallengeForWildcardDomain(t *testing.T) {
+	err := ValidateRoute(&proxy.Route{Domains: []string{"*.example.com"}, Challenge: "http"})
+
+	assert.Error(t, err)
+}
+
+func Test_ValidateRoute_allowsDnsChallengeForWildcardDomain(t *testing.T) {
+	err := ValidateRoute(&proxy.Route{Domains: []string{"*.example.com"}, Challenge: "dns"})
+
+	assert.NoError(t, err)
+}
+
+func Test_ValidateRoute_errorsOnRateLimitWithoutBurst(t *testing.T) {
+	err := ValidateRoute(&proxy.Route{Domains: []string{"example.com"}, RateLimitRPS: 5})
+
+	assert.Error(t, err)
+}
+
+func Test_ValidateRoute_allowsNoRateLimit(t *testing.T) {
+	err := ValidateRoute(&proxy.Route{Domains: []string{"example.com"}})
+
+	assert.NoError(t, err)
+}
+
+func Test_ValidateRoute_errorsOnUnknownHeaderOperation(t *testing.T) {
+	err := ValidateRoute(&proxy.Route{
+		Domains: []string{"example.com"},
+		Headers: []proxy.Header{{Operation: proxy.HeaderOp(99)}},
+	})
+
+	assert.Error(t, err)
+}
+
+func Test_ValidateRoute_allowsKnownHeaderOperations(t *testing.T) {
+	err := ValidateRoute(&proxy.Route{
+		Domains: []string{"example.com"},
+		Headers: []proxy.Header{{Operation: proxy.HeaderOpAdd}},
+	})
+
+	assert.NoError(t, err)
+}
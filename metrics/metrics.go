@@ -0,0 +1,81 @@
+// Package metrics holds the Prometheus collectors Centauri exposes on its
+// admin listener, so that other packages can record against them without
+// depending on how (or whether) they're served.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// RouteCount is the number of routes currently configured.
+	RouteCount = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "centauri_route_count",
+		Help: "Number of routes currently configured.",
+	})
+
+	// CertificateNotAfter is the expiry time of each route's certificate,
+	// as a Unix timestamp.
+	CertificateNotAfter = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "centauri_certificate_not_after_seconds",
+		Help: "Unix timestamp of each certificate's expiry.",
+	}, []string{"domain", "provider"})
+
+	// CertificateOCSPNextUpdate is the NextUpdate time of the most recently
+	// fetched OCSP staple for each domain, as a Unix timestamp.
+	CertificateOCSPNextUpdate = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "centauri_certificate_ocsp_next_update_seconds",
+		Help: "Unix timestamp of each certificate's next OCSP update.",
+	}, []string{"domain"})
+
+	// CertificateRenewalFailures counts failed attempts to obtain or renew
+	// a certificate, by provider.
+	CertificateRenewalFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "centauri_certificate_renewal_failures_total",
+		Help: "Number of certificate renewal failures, by provider.",
+	}, []string{"provider"})
+
+	// HTTPRequests counts proxied HTTP requests, by route, method and
+	// response status code.
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "centauri_http_requests_total",
+		Help: "Number of HTTP requests handled, by route, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	// UpstreamDialErrors counts failed attempts to connect to a route's
+	// upstream, by upstream address.
+	UpstreamDialErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "centauri_upstream_dial_errors_total",
+		Help: "Number of failed dial attempts to upstreams.",
+	}, []string{"upstream"})
+
+	// TLSHandshakes counts completed TLS handshakes, by negotiated version
+	// and whether the client's SNI matched a configured route.
+	TLSHandshakes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "centauri_tls_handshake_total",
+		Help: "Number of completed TLS handshakes, by negotiated version and whether SNI matched a route.",
+	}, []string{"version", "sni_matched"})
+
+	httpRequestDuration *prometheus.HistogramVec
+)
+
+// Init registers the centauri_http_request_duration_seconds histogram with
+// the given bucket boundaries. It must be called once, before the first
+// request is served; ObserveHTTPRequestDuration is a no-op until it has.
+func Init(buckets []float64) {
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "centauri_http_request_duration_seconds",
+		Help:    "Time taken to serve HTTP requests.",
+		Buckets: buckets,
+	}, []string{"route"})
+}
+
+// ObserveHTTPRequestDuration records how long a request to route took, in
+// seconds.
+func ObserveHTTPRequestDuration(route string, seconds float64) {
+	if httpRequestDuration == nil {
+		return
+	}
+	httpRequestDuration.WithLabelValues(route).Observe(seconds)
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ObserveHTTPRequestDuration_isNoOpBeforeInit(t *testing.T) {
+	previous := httpRequestDuration
+	httpRequestDuration = nil
+	defer func() { httpRequestDuration = previous }()
+
+	assert.NotPanics(t, func() {
+		ObserveHTTPRequestDuration("example.com", 1.5)
+	})
+}
+
+func Test_ObserveHTTPRequestDuration_recordsAfterInit(t *testing.T) {
+	// Built directly with prometheus.NewHistogramVec rather than calling
+	// Init (which registers against the global default registry via
+	// promauto), so this test can't panic on double registration or
+	// depend on running before any other test that calls Init.
+	previous := httpRequestDuration
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "centauri_http_request_duration_seconds",
+		Buckets: []float64{0.1, 1, 5},
+	}, []string{"route"})
+	defer func() { httpRequestDuration = previous }()
+
+	ObserveHTTPRequestDuration("example.com", 1.5)
+
+	assert.Equal(t, 1, testutil.CollectAndCount(httpRequestDuration))
+}
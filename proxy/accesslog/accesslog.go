@@ -0,0 +1,164 @@
+// Package accesslog records one structured entry per proxied request,
+// similar to the access logs produced by Traefik or nginx.
+package accesslog
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Format selects how entries are rendered.
+type Format string
+
+const (
+	// FormatJSON writes one JSON object per line.
+	FormatJSON Format = "json"
+	// FormatCommon writes entries in the Common Log Format.
+	FormatCommon Format = "common"
+)
+
+// Config controls where and how access log entries are written.
+type Config struct {
+	// Path is the file to append entries to. An empty path disables logging.
+	Path string
+	// Format selects the output format; defaults to FormatJSON.
+	Format Format
+	// TrustedProxies lists CIDRs that are allowed to supply a client IP via
+	// X-Forwarded-For; requests from any other source use the connecting
+	// address directly.
+	TrustedProxies []*net.IPNet
+	// ExcludePaths lists request path prefixes that should not be logged,
+	// to keep noisy health checks out of the log.
+	ExcludePaths []string
+	// MaxSizeBytes rotates the log once it grows past this size. Zero
+	// disables size-based rotation.
+	MaxSizeBytes int64
+	// MaxAge rotates the log once it's older than this. Zero disables
+	// age-based rotation.
+	MaxAge time.Duration
+}
+
+// Entry describes a single completed request.
+type Entry struct {
+	Time               time.Time
+	ClientIP           string
+	Domain             string
+	Upstream           string
+	Method             string
+	Path               string
+	Host               string
+	Status             int
+	BytesIn            int64
+	BytesOut           int64
+	Duration           time.Duration
+	TLSVersion         uint16
+	TLSCipherSuite     uint16
+	CertificateSubject string
+}
+
+// Logger writes access log entries to a rotating file.
+type Logger struct {
+	config Config
+
+	mutex sync.Mutex
+	file  *rotatingFile
+}
+
+// New creates a Logger from cfg. If cfg.Path is empty, the returned Logger's
+// Log method is a no-op.
+func New(cfg Config) (*Logger, error) {
+	if cfg.Format == "" {
+		cfg.Format = FormatJSON
+	}
+
+	l := &Logger{config: cfg}
+	if cfg.Path == "" {
+		return l, nil
+	}
+
+	file, err := newRotatingFile(cfg.Path, cfg.MaxSizeBytes, cfg.MaxAge)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open access log: %w", err)
+	}
+	l.file = file
+	return l, nil
+}
+
+// Log writes entry, unless its path is excluded or logging is disabled.
+func (l *Logger) Log(entry Entry) {
+	if l.file == nil {
+		return
+	}
+	for _, prefix := range l.config.ExcludePaths {
+		if strings.HasPrefix(entry.Path, prefix) {
+			return
+		}
+	}
+
+	var line string
+	if l.config.Format == FormatCommon {
+		line = formatCommon(entry)
+	} else {
+		line = formatJSON(entry)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	_, _ = l.file.WriteString(line + "\n")
+}
+
+// Reopen closes the log file and opens it again at the same path, without
+// renaming the existing file. It is safe to call even if logging is disabled.
+func (l *Logger) Reopen() error {
+	if l.file == nil {
+		return nil
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.file.reopen()
+}
+
+// ClientIP extracts the real client address from r, honouring
+// TrustedProxies for X-Forwarded-For.
+func (l *Logger) ClientIP(r *http.Request) string {
+	return ClientIP(r, l.config.TrustedProxies)
+}
+
+// ClientIP extracts the real client address from r. If the connecting
+// address is in trustedProxies, the leftmost address in X-Forwarded-For is
+// used instead; otherwise the connecting address is returned as-is.
+func ClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+
+	if !isTrusted(remote, trustedProxies) {
+		return remote
+	}
+
+	forwarded := r.Header.Get("X-Forwarded-For")
+	if forwarded == "" {
+		return remote
+	}
+
+	parts := strings.Split(forwarded, ",")
+	return strings.TrimSpace(parts[0])
+}
+
+func isTrusted(addr string, trustedProxies []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range trustedProxies {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
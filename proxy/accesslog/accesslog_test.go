@@ -0,0 +1,95 @@
+package accesslog
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustParseCIDR(t *testing.T, cidr string) *net.IPNet {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatalf("invalid CIDR %q: %v", cidr, err)
+	}
+	return ipNet
+}
+
+func Test_ClientIP_returnsRemoteAddrWhenNotTrusted(t *testing.T) {
+	r := &http.Request{
+		RemoteAddr: "203.0.113.1:1234",
+		Header:     http.Header{"X-Forwarded-For": []string{"198.51.100.1"}},
+	}
+
+	assert.Equal(t, "203.0.113.1", ClientIP(r, []*net.IPNet{mustParseCIDR(t, "10.0.0.0/8")}))
+}
+
+func Test_ClientIP_usesForwardedForWhenTrusted(t *testing.T) {
+	r := &http.Request{
+		RemoteAddr: "10.0.0.1:1234",
+		Header:     http.Header{"X-Forwarded-For": []string{"198.51.100.1, 10.0.0.1"}},
+	}
+
+	assert.Equal(t, "198.51.100.1", ClientIP(r, []*net.IPNet{mustParseCIDR(t, "10.0.0.0/8")}))
+}
+
+func Test_ClientIP_returnsRemoteAddrWhenTrustedButNoForwardedFor(t *testing.T) {
+	r := &http.Request{
+		RemoteAddr: "10.0.0.1:1234",
+		Header:     http.Header{},
+	}
+
+	assert.Equal(t, "10.0.0.1", ClientIP(r, []*net.IPNet{mustParseCIDR(t, "10.0.0.0/8")}))
+}
+
+func Test_ClientIP_handlesMissingPort(t *testing.T) {
+	r := &http.Request{
+		RemoteAddr: "203.0.113.1",
+		Header:     http.Header{},
+	}
+
+	assert.Equal(t, "203.0.113.1", ClientIP(r, nil))
+}
+
+func Test_TLSVersionName_returnsEmptyForUnknownVersion(t *testing.T) {
+	assert.Equal(t, "", TLSVersionName(0x9999))
+}
+
+func Test_TLSVersionName_returnsNameForKnownVersion(t *testing.T) {
+	assert.Equal(t, "TLS1.3", TLSVersionName(0x0304))
+}
+
+func Test_formatCommon_escapesQuotesAndNewlines(t *testing.T) {
+	entry := Entry{
+		ClientIP: "203.0.113.1",
+		Time:     time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Method:   "GET",
+		Path:     "/foo\"bar\nbaz",
+		Status:   200,
+		BytesOut: 42,
+	}
+
+	line := formatCommon(entry)
+	assert.NotContains(t, line, "\"bar")
+	assert.NotContains(t, line, "\n")
+	assert.Contains(t, line, "203.0.113.1")
+	assert.Contains(t, line, "200")
+}
+
+func Test_formatJSON_includesCoreFields(t *testing.T) {
+	entry := Entry{
+		ClientIP: "203.0.113.1",
+		Domain:   "example.com",
+		Method:   "GET",
+		Path:     "/",
+		Status:   200,
+		Duration: 1500 * time.Millisecond,
+	}
+
+	line := formatJSON(entry)
+	assert.Contains(t, line, `"clientIp":"203.0.113.1"`)
+	assert.Contains(t, line, `"domain":"example.com"`)
+	assert.Contains(t, line, `"durationMs":1500`)
+}
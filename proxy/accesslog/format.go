@@ -0,0 +1,93 @@
+package accesslog
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type jsonEntry struct {
+	Time               string `json:"time"`
+	ClientIP           string `json:"clientIp"`
+	Domain             string `json:"domain"`
+	Upstream           string `json:"upstream"`
+	Method             string `json:"method"`
+	Path               string `json:"path"`
+	Host               string `json:"host"`
+	Status             int    `json:"status"`
+	BytesIn            int64  `json:"bytesIn"`
+	BytesOut           int64  `json:"bytesOut"`
+	DurationMs         int64  `json:"durationMs"`
+	TLSVersion         string `json:"tlsVersion,omitempty"`
+	TLSCipherSuite     string `json:"tlsCipherSuite,omitempty"`
+	CertificateSubject string `json:"certificateSubject,omitempty"`
+}
+
+func formatJSON(e Entry) string {
+	data, err := json.Marshal(jsonEntry{
+		Time:               e.Time.Format("2006-01-02T15:04:05.000Z07:00"),
+		ClientIP:           e.ClientIP,
+		Domain:             e.Domain,
+		Upstream:           e.Upstream,
+		Method:             e.Method,
+		Path:               e.Path,
+		Host:               e.Host,
+		Status:             e.Status,
+		BytesIn:            e.BytesIn,
+		BytesOut:           e.BytesOut,
+		DurationMs:         e.Duration.Milliseconds(),
+		TLSVersion:         TLSVersionName(e.TLSVersion),
+		TLSCipherSuite:     tlsCipherSuiteName(e.TLSCipherSuite),
+		CertificateSubject: e.CertificateSubject,
+	})
+	if err != nil {
+		return fmt.Sprintf(`{"error":"unable to marshal access log entry: %s"}`, err)
+	}
+	return string(data)
+}
+
+// formatCommon renders e in the Common Log Format.
+func formatCommon(e Entry) string {
+	user := "-"
+	return fmt.Sprintf(
+		`%s - %s [%s] "%s %s HTTP/1.1" %d %d`,
+		e.ClientIP,
+		user,
+		e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		escapeCLF(e.Method),
+		escapeCLF(e.Path),
+		e.Status,
+		e.BytesOut,
+	)
+}
+
+// escapeCLF strips characters that would let a request forge fields in the
+// quoted request-line part of a Common Log Format record.
+func escapeCLF(s string) string {
+	return strings.NewReplacer(`"`, "", "\n", "", "\r", "").Replace(s)
+}
+
+// TLSVersionName returns the human-readable name of a tls.VersionTLS*
+// constant, or "" if it isn't recognised.
+func TLSVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS1.0"
+	case tls.VersionTLS11:
+		return "TLS1.1"
+	case tls.VersionTLS12:
+		return "TLS1.2"
+	case tls.VersionTLS13:
+		return "TLS1.3"
+	default:
+		return ""
+	}
+}
+
+func tlsCipherSuiteName(suite uint16) string {
+	if suite == 0 {
+		return ""
+	}
+	return tls.CipherSuiteName(suite)
+}
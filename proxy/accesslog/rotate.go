@@ -0,0 +1,95 @@
+package accesslog
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// rotatingFile is an append-only file that rotates itself aside once it
+// grows past a size or age limit. It can also be reopened explicitly (e.g.
+// in response to SIGHUP), which does not rotate it.
+type rotatingFile struct {
+	path     string
+	maxSize  int64
+	maxAge   time.Duration
+	openedAt time.Time
+
+	file *os.File
+	size int64
+}
+
+func newRotatingFile(path string, maxSize int64, maxAge time.Duration) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, maxSize: maxSize, maxAge: maxAge}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	r.file = file
+	r.size = info.Size()
+	r.openedAt = time.Now()
+	return nil
+}
+
+// WriteString appends s to the file, rotating first if the file has grown
+// or aged past its limits.
+func (r *rotatingFile) WriteString(s string) (int, error) {
+	if r.shouldRotate() {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.WriteString(s)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) shouldRotate() bool {
+	if r.maxSize > 0 && r.size >= r.maxSize {
+		return true
+	}
+	if r.maxAge > 0 && time.Since(r.openedAt) >= r.maxAge {
+		return true
+	}
+	return false
+}
+
+// rotate renames the current file aside with a timestamp suffix and opens a
+// fresh one in its place.
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+
+	rotatedPath := fmt.Sprintf("%s.%s", r.path, time.Now().Format("20060102T150405"))
+	if err := os.Rename(r.path, rotatedPath); err != nil {
+		return err
+	}
+
+	return r.open()
+}
+
+// reopen closes the file and opens it again at the same path, without
+// renaming it. Used to handle SIGHUP in deployments where the log is rotated externally
+// (e.g. logrotate) and the inode has changed.
+func (r *rotatingFile) reopen() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	return r.open()
+}
@@ -0,0 +1,34 @@
+package accesslog
+
+import (
+	"fmt"
+	"net"
+)
+
+// ParseTrustedProxies parses a list of CIDR strings (e.g. from a
+// space-separated flag) into the form Config.TrustedProxies expects. Bare IP
+// addresses are treated as a /32 (or /128 for IPv6).
+func ParseTrustedProxies(values []string) ([]*net.IPNet, error) {
+	var result []*net.IPNet
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+
+		if _, cidr, err := net.ParseCIDR(value); err == nil {
+			result = append(result, cidr)
+			continue
+		}
+
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", value)
+		}
+		bits := 32
+		if ip.To4() == nil {
+			bits = 128
+		}
+		result = append(result, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return result, nil
+}
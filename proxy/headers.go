@@ -0,0 +1,23 @@
+package proxy
+
+// HeaderOp describes how a header directive should be applied to a proxied
+// request.
+type HeaderOp int
+
+const (
+	// HeaderOpAdd appends a new header, leaving any existing values in place.
+	HeaderOpAdd HeaderOp = iota
+	// HeaderOpDelete removes all values of a header.
+	HeaderOpDelete
+	// HeaderOpDefault sets a header only if it isn't already present.
+	HeaderOpDefault
+	// HeaderOpReplace overwrites a header only if it is already present.
+	HeaderOpReplace
+)
+
+// Header is a single header manipulation to apply to a request for a route.
+type Header struct {
+	Name      string
+	Value     string
+	Operation HeaderOp
+}
@@ -0,0 +1,245 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/csmith/centauri/metrics"
+)
+
+// CertificateProvider supplies TLS certificates for a given subject, optionally
+// honouring a preferred supplier name configured on the route.
+type CertificateProvider interface {
+	GetCertificate(preferredSupplier string, subject string, altNames []string) (*tls.Certificate, error)
+}
+
+// Route describes a single proxied host: the domains it answers for, where
+// traffic should be sent, and how it should be modified along the way.
+type Route struct {
+	Domains        []string
+	Upstream       string
+	Provider       string
+	Headers        []Header
+	Challenge      string
+	MustStaple     bool
+	RateLimitRPS   float64
+	RateLimitBurst int
+
+	certMutex   sync.RWMutex
+	certificate *tls.Certificate
+}
+
+func (r *Route) setCertificate(cert *tls.Certificate) {
+	r.certMutex.Lock()
+	defer r.certMutex.Unlock()
+	r.certificate = cert
+}
+
+func (r *Route) getCertificate() *tls.Certificate {
+	r.certMutex.RLock()
+	defer r.certMutex.RUnlock()
+	return r.certificate
+}
+
+// Manager holds the set of active routes and resolves certificates/upstreams
+// for incoming connections.
+type Manager struct {
+	certProvider CertificateProvider
+
+	mutex    sync.RWMutex
+	routes   []*Route
+	byDomain map[string]*Route
+}
+
+// NewManager creates a Manager that obtains certificates from the given
+// provider. A nil provider means no certificates will be issued, which is
+// useful for plain-HTTP-only deployments.
+func NewManager(certProvider CertificateProvider) *Manager {
+	return &Manager{
+		certProvider: certProvider,
+		byDomain:     map[string]*Route{},
+	}
+}
+
+// RouteError reports that setting up a specific route failed, identifying
+// which domain was at fault rather than leaving callers to parse an error
+// string (useful for the admin API, which reports it back as JSON).
+type RouteError struct {
+	Domain string
+	Err    error
+}
+
+func (e *RouteError) Error() string {
+	return fmt.Sprintf("route %s: %v", e.Domain, e.Err)
+}
+
+func (e *RouteError) Unwrap() error {
+	return e.Err
+}
+
+// SetRoutes replaces the active set of routes, fetching a certificate for
+// each one before the change is applied. It's transactional: routes and
+// certificates are validated and fetched into a staging map first, and the
+// active set is only swapped once every route has succeeded, so a bad
+// route (or an ACME outage) can't take down routes that were already
+// working. On failure, the previous routes are left in place.
+func (m *Manager) SetRoutes(routes []*Route) error {
+	byDomain := make(map[string]*Route, len(routes))
+
+	for _, route := range routes {
+		if err := validateDomains(route.Domains); err != nil {
+			return &RouteError{Domain: strings.Join(route.Domains, ","), Err: err}
+		}
+
+		if err := m.fetchAndApplyCertificate(route); err != nil {
+			return &RouteError{Domain: route.Domains[0], Err: fmt.Errorf("unable to obtain certificate: %w", err)}
+		}
+
+		for _, domain := range route.Domains {
+			byDomain[domain] = route
+		}
+	}
+
+	m.mutex.Lock()
+	m.routes = routes
+	m.byDomain = byDomain
+	m.mutex.Unlock()
+
+	metrics.RouteCount.Set(float64(len(routes)))
+	return nil
+}
+
+// RouteForDomain returns the route configured for the given domain, or nil
+// if no route matches.
+func (m *Manager) RouteForDomain(domain string) *Route {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.byDomain[domain]
+}
+
+// Routes returns the currently active set of routes.
+func (m *Manager) Routes() []*Route {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.routes
+}
+
+// RenewRoute re-checks the certificate for the route matching domain,
+// refreshing it if the provider considers it due for renewal. It returns an
+// error if no route matches domain, or if the check itself fails.
+func (m *Manager) RenewRoute(domain string) error {
+	route := m.RouteForDomain(domain)
+	if route == nil {
+		return fmt.Errorf("no route configured for %s", domain)
+	}
+
+	if err := m.fetchAndApplyCertificate(route); err != nil {
+		return &RouteError{Domain: route.Domains[0], Err: err}
+	}
+	return nil
+}
+
+// CertificateForClient is used as the tls.Config.GetCertificate callback; it
+// resolves the route matching the client's SNI and returns its certificate.
+func (m *Manager) CertificateForClient(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	route := m.RouteForDomain(hello.ServerName)
+	if route == nil {
+		return nil, nil
+	}
+	cert := route.getCertificate()
+	if cert == nil {
+		return nil, fmt.Errorf("no certificate available for %s", hello.ServerName)
+	}
+	return cert, nil
+}
+
+// CheckCertificates refreshes the certificate for every active route. A
+// route whose certificate fails to refresh is recorded via the
+// centauri_certificate_renewal_failures_total metric and left on its
+// current certificate rather than aborting the remaining routes; the first
+// error encountered is still returned so callers can log it.
+func (m *Manager) CheckCertificates() error {
+	if m.certProvider == nil {
+		return nil
+	}
+
+	m.mutex.RLock()
+	routes := m.routes
+	m.mutex.RUnlock()
+
+	var firstErr error
+	for _, route := range routes {
+		if err := m.fetchAndApplyCertificate(route); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("unable to refresh certificate for %s: %w", route.Domains[0], err)
+			}
+		}
+	}
+	return firstErr
+}
+
+// fetchAndApplyCertificate obtains a fresh certificate for route from
+// m.certProvider and installs it, recording the relevant metrics either way.
+// It's a no-op if no certificate provider is configured.
+func (m *Manager) fetchAndApplyCertificate(route *Route) error {
+	if m.certProvider == nil {
+		return nil
+	}
+
+	cert, err := m.certProvider.GetCertificate(route.Provider, route.Domains[0], route.Domains[1:])
+	if err != nil {
+		metrics.CertificateRenewalFailures.WithLabelValues(route.Provider).Inc()
+		return err
+	}
+	route.setCertificate(cert)
+	recordCertificateMetrics(route, cert)
+	return nil
+}
+
+// certificateSubject returns the common name of the certificate presented
+// for this route, or an empty string if it isn't known.
+func (r *Route) certificateSubject() string {
+	leaf := parseLeaf(r.getCertificate())
+	if leaf == nil {
+		return ""
+	}
+	return leaf.Subject.CommonName
+}
+
+// parseLeaf returns the leaf certificate of cert, or nil if it's missing or
+// unparseable.
+func parseLeaf(cert *tls.Certificate) *x509.Certificate {
+	if cert == nil || len(cert.Certificate) == 0 {
+		return nil
+	}
+	if cert.Leaf != nil {
+		return cert.Leaf
+	}
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil
+	}
+	return parsed
+}
+
+// recordCertificateMetrics updates centauri_certificate_not_after_seconds
+// for route's newly fetched certificate.
+func recordCertificateMetrics(route *Route, cert *tls.Certificate) {
+	leaf := parseLeaf(cert)
+	if leaf == nil || len(route.Domains) == 0 {
+		return
+	}
+	metrics.CertificateNotAfter.WithLabelValues(route.Domains[0], route.Provider).Set(float64(leaf.NotAfter.Unix()))
+}
+
+func validateDomains(domains []string) error {
+	for _, domain := range domains {
+		if domain == "" || strings.Contains(domain, "..") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+			return fmt.Errorf("invalid domain: %q", domain)
+		}
+	}
+	return nil
+}
@@ -224,3 +224,87 @@ func Test_Manager_CheckCertificates_returnsIfNoProvider(t *testing.T) {
 	err := manager.CheckCertificates()
 	assert.NoError(t, err)
 }
+
+func Test_Manager_SetRoutes_returnsRouteErrorIfGetCertificateFails(t *testing.T) {
+	certManager := &fakeCertManager{
+		err: fmt.Errorf("ruh roh"),
+	}
+
+	manager := NewManager(certManager)
+	err := manager.SetRoutes([]*Route{{
+		Domains: []string{"example.com"},
+	}})
+
+	var routeErr *RouteError
+	require.ErrorAs(t, err, &routeErr)
+	assert.Equal(t, "example.com", routeErr.Domain)
+}
+
+func Test_Manager_SetRoutes_leavesPreviousRoutesOnFailure(t *testing.T) {
+	certManager := &fakeCertManager{
+		certificate: dummyCert,
+	}
+
+	manager := NewManager(certManager)
+	require.NoError(t, manager.SetRoutes([]*Route{{
+		Domains: []string{"example.com"},
+	}}))
+
+	certManager.err = fmt.Errorf("ruh roh")
+	err := manager.SetRoutes([]*Route{{
+		Domains: []string{"example.net"},
+	}})
+	assert.Error(t, err)
+
+	assert.NotNil(t, manager.RouteForDomain("example.com"))
+	assert.Nil(t, manager.RouteForDomain("example.net"))
+}
+
+func Test_Manager_Routes_returnsActiveRoutes(t *testing.T) {
+	manager := NewManager(nil)
+	route := &Route{Domains: []string{"example.com"}}
+	require.NoError(t, manager.SetRoutes([]*Route{route}))
+
+	assert.Equal(t, []*Route{route}, manager.Routes())
+}
+
+func Test_Manager_RenewRoute_returnsErrorIfNoRouteFound(t *testing.T) {
+	manager := NewManager(nil)
+	err := manager.RenewRoute("example.com")
+	assert.Error(t, err)
+}
+
+func Test_Manager_RenewRoute_updatesCertificate(t *testing.T) {
+	certManager := &fakeCertManager{
+		certificate: dummyCert,
+	}
+
+	manager := NewManager(certManager)
+	require.NoError(t, manager.SetRoutes([]*Route{{
+		Domains: []string{"example.com"},
+	}}))
+
+	newCert := &tls.Certificate{OCSPStaple: []byte("Different!")}
+	certManager.certificate = newCert
+	require.NoError(t, manager.RenewRoute("example.com"))
+
+	assert.Equal(t, newCert, manager.RouteForDomain("example.com").certificate)
+}
+
+func Test_Manager_RenewRoute_returnsRouteErrorIfGetCertificateFails(t *testing.T) {
+	certManager := &fakeCertManager{
+		certificate: dummyCert,
+	}
+
+	manager := NewManager(certManager)
+	require.NoError(t, manager.SetRoutes([]*Route{{
+		Domains: []string{"example.com"},
+	}}))
+
+	certManager.err = fmt.Errorf("ruh roh")
+	err := manager.RenewRoute("example.com")
+
+	var routeErr *RouteError
+	require.ErrorAs(t, err, &routeErr)
+	assert.Equal(t, "example.com", routeErr.Domain)
+}
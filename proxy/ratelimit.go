@@ -0,0 +1,161 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/csmith/centauri/proxy/accesslog"
+)
+
+// bucketIdleTimeout is how long a per-route-per-IP bucket can go unused
+// before it's eligible for eviction by the cleanup sweep.
+const bucketIdleTimeout = 10 * time.Minute
+
+// RateLimiter enforces each route's `ratelimit` directive (a token bucket
+// per route and client IP), plus an optional global cap on concurrent
+// connections to any single upstream.
+type RateLimiter struct {
+	trustedProxies []*net.IPNet
+
+	bucketsMutex sync.Mutex
+	buckets      map[string]*tokenBucket
+
+	maxUpstreamConns int
+	upstreamMutex    sync.Mutex
+	upstreamSlots    map[string]chan struct{}
+}
+
+// NewRateLimiter creates a RateLimiter. trustedProxies controls which
+// sources are allowed to supply a client IP via X-Forwarded-For when
+// keying the per-route buckets. maxUpstreamConns caps concurrent requests
+// to any single upstream; zero disables the cap.
+func NewRateLimiter(trustedProxies []*net.IPNet, maxUpstreamConns int) *RateLimiter {
+	l := &RateLimiter{
+		trustedProxies:   trustedProxies,
+		buckets:          map[string]*tokenBucket{},
+		maxUpstreamConns: maxUpstreamConns,
+		upstreamSlots:    map[string]chan struct{}{},
+	}
+	go l.cleanupLoop()
+	return l
+}
+
+// cleanupLoop periodically evicts buckets that haven't been touched
+// recently, so memory doesn't grow without bound as new client IPs are seen.
+func (l *RateLimiter) cleanupLoop() {
+	ticker := time.NewTicker(bucketIdleTimeout)
+	defer ticker.Stop()
+	for range ticker.C {
+		now := time.Now()
+		l.bucketsMutex.Lock()
+		for key, bucket := range l.buckets {
+			if bucket.idleSince(now) > bucketIdleTimeout {
+				delete(l.buckets, key)
+			}
+		}
+		l.bucketsMutex.Unlock()
+	}
+}
+
+// Allow reports whether a request for route should proceed. If not,
+// retryAfter is how long the client should wait before retrying.
+func (l *RateLimiter) Allow(route *Route, r *http.Request) (bool, time.Duration) {
+	if route.RateLimitRPS <= 0 {
+		return true, 0
+	}
+
+	key := route.rateLimitKey() + "|" + accesslog.ClientIP(r, l.trustedProxies)
+
+	l.bucketsMutex.Lock()
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = newTokenBucket(route.RateLimitRPS, route.RateLimitBurst)
+		l.buckets[key] = bucket
+	}
+	l.bucketsMutex.Unlock()
+
+	return bucket.take()
+}
+
+// AcquireUpstream reserves a connection slot for upstream, blocking until
+// one is free if the cap has been reached, or until ctx is cancelled. On
+// success it returns a function that must be called to release the slot
+// once the request has finished, and ok is true; if ctx is cancelled first,
+// ok is false and there is nothing to release.
+func (l *RateLimiter) AcquireUpstream(ctx context.Context, upstream string) (release func(), ok bool) {
+	if l.maxUpstreamConns <= 0 {
+		return func() {}, true
+	}
+
+	l.upstreamMutex.Lock()
+	slots, ok := l.upstreamSlots[upstream]
+	if !ok {
+		slots = make(chan struct{}, l.maxUpstreamConns)
+		l.upstreamSlots[upstream] = slots
+	}
+	l.upstreamMutex.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, true
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+// rateLimitKey identifies a route for bucket keying. The primary domain is
+// used rather than the Route pointer so buckets survive a config reload.
+func (r *Route) rateLimitKey() string {
+	if len(r.Domains) == 0 {
+		return ""
+	}
+	return r.Domains[0]
+}
+
+// tokenBucket is a classic token-bucket limiter: tokens accrue at rate per
+// second up to burst capacity, and each request consumes one token.
+type tokenBucket struct {
+	mutex      sync.Mutex
+	rate       float64
+	burst      float64
+	tokens     float64
+	lastRefill time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	capacity := float64(burst)
+	if capacity <= 0 {
+		capacity = rate
+	}
+	return &tokenBucket{rate: rate, burst: capacity, tokens: capacity, lastRefill: time.Now()}
+}
+
+func (b *tokenBucket) take() (bool, time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.lastRefill).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.lastRefill = now
+
+	if b.tokens < 1 {
+		retryAfter := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		return false, retryAfter
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+// idleSince returns how long it's been since the bucket was last touched.
+func (b *tokenBucket) idleSince(now time.Time) time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return now.Sub(b.lastRefill)
+}
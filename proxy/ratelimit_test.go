@@ -0,0 +1,117 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TokenBucket_take_allowsUpToBurst(t *testing.T) {
+	bucket := newTokenBucket(1, 3)
+
+	for i := 0; i < 3; i++ {
+		allowed, _ := bucket.take()
+		assert.True(t, allowed)
+	}
+
+	allowed, retryAfter := bucket.take()
+	assert.False(t, allowed)
+	assert.Greater(t, retryAfter, time.Duration(0))
+}
+
+func Test_TokenBucket_take_refillsOverTime(t *testing.T) {
+	bucket := newTokenBucket(1, 1)
+
+	allowed, _ := bucket.take()
+	assert.True(t, allowed)
+
+	allowed, _ = bucket.take()
+	assert.False(t, allowed)
+
+	bucket.lastRefill = bucket.lastRefill.Add(-2 * time.Second)
+	allowed, _ = bucket.take()
+	assert.True(t, allowed)
+}
+
+func Test_TokenBucket_take_capsTokensAtBurst(t *testing.T) {
+	bucket := newTokenBucket(1, 2)
+
+	bucket.lastRefill = bucket.lastRefill.Add(-time.Hour)
+	bucket.take()
+
+	assert.LessOrEqual(t, bucket.tokens, bucket.burst)
+}
+
+func Test_RateLimiter_Allow_allowsWhenNoLimitConfigured(t *testing.T) {
+	limiter := NewRateLimiter(nil, 0)
+	route := &Route{Domains: []string{"example.com"}}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	allowed, _ := limiter.Allow(route, r)
+	assert.True(t, allowed)
+}
+
+func Test_RateLimiter_Allow_limitsPerRouteAndClientIP(t *testing.T) {
+	limiter := NewRateLimiter(nil, 0)
+	route := &Route{Domains: []string{"example.com"}, RateLimitRPS: 1, RateLimitBurst: 1}
+
+	r1 := httptest.NewRequest(http.MethodGet, "/", nil)
+	r1.RemoteAddr = "203.0.113.1:1234"
+	r2 := httptest.NewRequest(http.MethodGet, "/", nil)
+	r2.RemoteAddr = "203.0.113.2:1234"
+
+	allowed, _ := limiter.Allow(route, r1)
+	assert.True(t, allowed)
+
+	allowed, _ = limiter.Allow(route, r1)
+	assert.False(t, allowed)
+
+	// A different client IP gets its own bucket.
+	allowed, _ = limiter.Allow(route, r2)
+	assert.True(t, allowed)
+}
+
+func Test_RateLimiter_AcquireUpstream_allowsUnlimitedByDefault(t *testing.T) {
+	limiter := NewRateLimiter(nil, 0)
+
+	release, ok := limiter.AcquireUpstream(context.Background(), "upstream1")
+	assert.True(t, ok)
+	release()
+}
+
+func Test_RateLimiter_AcquireUpstream_blocksUntilSlotFreed(t *testing.T) {
+	limiter := NewRateLimiter(nil, 1)
+
+	release, ok := limiter.AcquireUpstream(context.Background(), "upstream1")
+	assert.True(t, ok)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, ok = limiter.AcquireUpstream(ctx, "upstream1")
+	assert.False(t, ok)
+
+	release()
+
+	release2, ok := limiter.AcquireUpstream(context.Background(), "upstream1")
+	assert.True(t, ok)
+	release2()
+}
+
+func Test_RateLimiter_AcquireUpstream_returnsFalseIfContextCancelled(t *testing.T) {
+	limiter := NewRateLimiter(nil, 1)
+
+	release, ok := limiter.AcquireUpstream(context.Background(), "upstream1")
+	assert.True(t, ok)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, ok = limiter.AcquireUpstream(ctx, "upstream1")
+	assert.False(t, ok)
+}
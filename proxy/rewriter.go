@@ -0,0 +1,193 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"time"
+
+	"github.com/csmith/centauri/metrics"
+	"github.com/csmith/centauri/proxy/accesslog"
+)
+
+// Rewriter is an http.Handler that proxies a request to the upstream
+// configured for the route matching its Host header, applying the route's
+// header operations and rate limits along the way.
+type Rewriter struct {
+	manager     *Manager
+	accessLog   *accesslog.Logger
+	rateLimiter *RateLimiter
+}
+
+// NewRewriter creates a Rewriter that resolves routes from the given
+// Manager. A nil accessLog disables access logging.
+func NewRewriter(manager *Manager, accessLog *accesslog.Logger, rateLimiter *RateLimiter) *Rewriter {
+	if rateLimiter == nil {
+		rateLimiter = NewRateLimiter(nil, 0)
+	}
+	return &Rewriter{manager: manager, accessLog: accessLog, rateLimiter: rateLimiter}
+}
+
+func (rw *Rewriter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	domain := stripPort(r.Host)
+	route := rw.manager.RouteForDomain(domain)
+	if route == nil || route.Upstream == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	start := time.Now()
+
+	if allowed, retryAfter := rw.rateLimiter.Allow(route, r); !allowed {
+		w.Header().Set("Retry-After", fmt.Sprintf("%.0f", retryAfter.Seconds()))
+		http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+		rw.logAccess(r, route, domain, &statusRecorder{ResponseWriter: w, status: http.StatusTooManyRequests}, start)
+		return
+	}
+
+	release, ok := rw.rateLimiter.AcquireUpstream(r.Context(), route.Upstream)
+	if !ok {
+		rw.logAccess(r, route, domain, &statusRecorder{ResponseWriter: w, status: http.StatusServiceUnavailable}, start)
+		return
+	}
+	defer release()
+
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+	reverseProxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = "http"
+			req.URL.Host = route.Upstream
+			applyHeaders(req, route.Headers)
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			// Client disconnects surface here too (context.Canceled); only
+			// genuine upstream failures should count towards the dial-error
+			// metric, or it'll be too noisy to alert on.
+			if !errors.Is(err, context.Canceled) {
+				metrics.UpstreamDialErrors.WithLabelValues(route.Upstream).Inc()
+			}
+			log.Printf("http: proxy error for %s -> %s: %v", r.Host, route.Upstream, err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	reverseProxy.ServeHTTP(recorder, r)
+
+	rw.logAccess(r, route, domain, recorder, start)
+}
+
+func (rw *Rewriter) logAccess(r *http.Request, route *Route, domain string, recorder *statusRecorder, start time.Time) {
+	recordRequestMetrics(route, r, recorder, start)
+
+	if rw.accessLog == nil {
+		return
+	}
+
+	bytesIn := r.ContentLength
+	if bytesIn < 0 {
+		bytesIn = 0
+	}
+
+	entry := accesslog.Entry{
+		Time:     start,
+		ClientIP: rw.accessLog.ClientIP(r),
+		Domain:   domain,
+		Upstream: route.Upstream,
+		Method:   r.Method,
+		Path:     r.URL.Path,
+		Host:     r.Host,
+		Status:   recorder.status,
+		BytesIn:  bytesIn,
+		BytesOut: recorder.bytesOut,
+		Duration: time.Since(start),
+	}
+
+	if r.TLS != nil {
+		entry.TLSVersion = r.TLS.Version
+		entry.TLSCipherSuite = r.TLS.CipherSuite
+		entry.CertificateSubject = route.certificateSubject()
+	}
+
+	rw.accessLog.Log(entry)
+}
+
+// recordRequestMetrics updates the request count and duration metrics for
+// route, regardless of whether access logging is enabled.
+func recordRequestMetrics(route *Route, r *http.Request, recorder *statusRecorder, start time.Time) {
+	routeLabel := ""
+	if len(route.Domains) > 0 {
+		routeLabel = route.Domains[0]
+	}
+
+	metrics.HTTPRequests.WithLabelValues(routeLabel, r.Method, strconv.Itoa(recorder.status)).Inc()
+	metrics.ObserveHTTPRequestDuration(routeLabel, time.Since(start).Seconds())
+}
+
+func applyHeaders(r *http.Request, headers []Header) {
+	for _, header := range headers {
+		switch header.Operation {
+		case HeaderOpAdd:
+			r.Header.Add(header.Name, header.Value)
+		case HeaderOpDelete:
+			r.Header.Del(header.Name)
+		case HeaderOpDefault:
+			if r.Header.Get(header.Name) == "" {
+				r.Header.Set(header.Name, header.Value)
+			}
+		case HeaderOpReplace:
+			if r.Header.Get(header.Name) != "" {
+				r.Header.Set(header.Name, header.Value)
+			}
+		}
+	}
+}
+
+func stripPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
+
+// statusRecorder wraps an http.ResponseWriter to capture the status code and
+// response size for access logging.
+type statusRecorder struct {
+	http.ResponseWriter
+	status   int
+	bytesOut int64
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	n, err := s.ResponseWriter.Write(b)
+	s.bytesOut += int64(n)
+	return n, err
+}
+
+// Flush forwards to the underlying ResponseWriter so streamed/chunked
+// responses still flush incrementally through the proxy.
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack forwards to the underlying ResponseWriter so protocol upgrades
+// (e.g. WebSockets) still work through the proxy.
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("underlying ResponseWriter does not support hijacking")
+	}
+	return hijacker.Hijack()
+}
@@ -0,0 +1,134 @@
+// Package tcp implements the default Centauri frontend: a plain HTTP
+// listener on port 80 and a TLS listener with SNI-based routing on port 443.
+package tcp
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/csmith/centauri/certificate"
+	"github.com/csmith/centauri/metrics"
+	"github.com/csmith/centauri/proxy"
+	"github.com/csmith/centauri/proxy/accesslog"
+)
+
+const acmeChallengePrefix = "/.well-known/acme-challenge/"
+
+// Frontend serves proxied traffic over plain TCP, handling ACME HTTP-01 and
+// TLS-ALPN-01 validation itself before falling through to the normal proxy
+// path.
+type Frontend struct {
+	httpAddr   string
+	httpsAddr  string
+	challenges *certificate.ChallengeStore
+
+	httpServer  *http.Server
+	httpsServer *http.Server
+}
+
+// New creates a Frontend listening on the standard HTTP/HTTPS ports. A nil
+// challenges store disables HTTP-01/TLS-ALPN-01 interception.
+func New(challenges *certificate.ChallengeStore) *Frontend {
+	return &Frontend{
+		httpAddr:   ":80",
+		httpsAddr:  ":443",
+		challenges: challenges,
+	}
+}
+
+// Serve starts the HTTP and HTTPS listeners, blocking until one of them
+// fails.
+func (f *Frontend) Serve(manager *proxy.Manager, rewriter *proxy.Rewriter) error {
+	nextProtos := []string{"h2", "http/1.1"}
+	if f.challenges != nil {
+		nextProtos = append(nextProtos, tlsAlpnProtocol)
+	}
+
+	f.httpsServer = &http.Server{
+		Addr:    f.httpsAddr,
+		Handler: rewriter,
+		TLSConfig: &tls.Config{
+			NextProtos:       nextProtos,
+			GetCertificate:   f.certificateForClient(manager),
+			VerifyConnection: recordHandshakeMetrics(manager),
+		},
+	}
+	f.httpServer = &http.Server{
+		Addr:    f.httpAddr,
+		Handler: f.httpHandler(rewriter),
+	}
+
+	errs := make(chan error, 2)
+	go func() { errs <- f.httpServer.ListenAndServe() }()
+	go func() { errs <- f.httpsServer.ListenAndServeTLS("", "") }()
+
+	if err := <-errs; err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Stop gracefully shuts down both listeners.
+func (f *Frontend) Stop(ctx context.Context) error {
+	if err := f.httpServer.Shutdown(ctx); err != nil {
+		return err
+	}
+	return f.httpsServer.Shutdown(ctx)
+}
+
+// httpHandler answers HTTP-01 challenge requests directly, and otherwise
+// proxies via the rewriter (rather than redirecting to https, so that plain
+// HTTP upstreams keep working).
+func (f *Frontend) httpHandler(rewriter *proxy.Rewriter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if f.challenges != nil && strings.HasPrefix(r.URL.Path, acmeChallengePrefix) {
+			token := strings.TrimPrefix(r.URL.Path, acmeChallengePrefix)
+			if keyAuth, ok := f.challenges.KeyAuthorization(token); ok {
+				w.Header().Set("Content-Type", "text/plain")
+				_, _ = w.Write([]byte(keyAuth))
+				return
+			}
+			http.NotFound(w, r)
+			return
+		}
+		rewriter.ServeHTTP(w, r)
+	})
+}
+
+// certificateForClient intercepts the TLS-ALPN-01 handshake before falling
+// back to the route's normal certificate.
+func (f *Frontend) certificateForClient(manager *proxy.Manager) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if f.challenges != nil && supportsTLSALPN(hello) {
+			if cert, ok := f.challenges.CertificateForSNI(hello.ServerName); ok {
+				return cert, nil
+			}
+		}
+		return manager.CertificateForClient(hello)
+	}
+}
+
+// recordHandshakeMetrics returns a tls.Config.VerifyConnection callback that
+// counts completed handshakes by negotiated version and whether the
+// client's SNI matched a configured route, without affecting verification.
+func recordHandshakeMetrics(manager *proxy.Manager) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		sniMatched := manager.RouteForDomain(cs.ServerName) != nil
+		metrics.TLSHandshakes.WithLabelValues(accesslog.TLSVersionName(cs.Version), strconv.FormatBool(sniMatched)).Inc()
+		return nil
+	}
+}
+
+const tlsAlpnProtocol = "acme-tls/1"
+
+func supportsTLSALPN(hello *tls.ClientHelloInfo) bool {
+	for _, proto := range hello.SupportedProtos {
+		if proto == tlsAlpnProtocol {
+			return true
+		}
+	}
+	return false
+}
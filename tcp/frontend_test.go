@@ -0,0 +1,101 @@
+package tcp
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/csmith/centauri/certificate"
+	"github.com/csmith/centauri/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_httpHandler_answersInFlightHTTP01Challenge(t *testing.T) {
+	challenges := certificate.NewChallengeStore()
+	require.NoError(t, challenges.HTTPProvider().Present("example.com", "token", "key-auth"))
+
+	frontend := New(challenges)
+	manager := proxy.NewManager(nil)
+	rewriter := proxy.NewRewriter(manager, nil, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, acmeChallengePrefix+"token", nil)
+	frontend.httpHandler(rewriter).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "key-auth", w.Body.String())
+}
+
+func Test_httpHandler_returnsNotFoundForUnknownChallengeToken(t *testing.T) {
+	frontend := New(certificate.NewChallengeStore())
+	manager := proxy.NewManager(nil)
+	rewriter := proxy.NewRewriter(manager, nil, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, acmeChallengePrefix+"unknown", nil)
+	frontend.httpHandler(rewriter).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func Test_httpHandler_fallsThroughToRewriterForNonChallengeRequests(t *testing.T) {
+	frontend := New(certificate.NewChallengeStore())
+	manager := proxy.NewManager(nil)
+	rewriter := proxy.NewRewriter(manager, nil, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	frontend.httpHandler(rewriter).ServeHTTP(w, r)
+
+	// No route is configured, so the rewriter itself answers 404 - this
+	// confirms the request reached it rather than being handled here.
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func Test_httpHandler_ignoresChallengePrefixWithNilChallengeStore(t *testing.T) {
+	frontend := New(nil)
+	manager := proxy.NewManager(nil)
+	rewriter := proxy.NewRewriter(manager, nil, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, acmeChallengePrefix+"token", nil)
+	frontend.httpHandler(rewriter).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func Test_certificateForClient_answersInFlightTLSALPNChallenge(t *testing.T) {
+	challenges := certificate.NewChallengeStore()
+	require.NoError(t, challenges.TLSALPNProvider().Present("example.com", "token", "key-auth"))
+
+	frontend := New(challenges)
+	manager := proxy.NewManager(nil)
+
+	cert, err := frontend.certificateForClient(manager)(&tls.ClientHelloInfo{
+		ServerName:      "example.com",
+		SupportedProtos: []string{tlsAlpnProtocol},
+	})
+
+	require.NoError(t, err)
+	require.NotNil(t, cert)
+}
+
+func Test_certificateForClient_fallsBackToManagerWithoutTLSALPN(t *testing.T) {
+	frontend := New(certificate.NewChallengeStore())
+	manager := proxy.NewManager(nil)
+
+	cert, err := frontend.certificateForClient(manager)(&tls.ClientHelloInfo{ServerName: "example.com"})
+
+	assert.NoError(t, err)
+	assert.Nil(t, cert)
+}
+
+func Test_supportsTLSALPN_trueWhenProtocolNegotiated(t *testing.T) {
+	assert.True(t, supportsTLSALPN(&tls.ClientHelloInfo{SupportedProtos: []string{"h2", tlsAlpnProtocol}}))
+}
+
+func Test_supportsTLSALPN_falseWhenProtocolAbsent(t *testing.T) {
+	assert.False(t, supportsTLSALPN(&tls.ClientHelloInfo{SupportedProtos: []string{"h2", "http/1.1"}}))
+}